@@ -0,0 +1,216 @@
+package api
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lancer2672/BookingAppSubServer/db"
+	"github.com/lancer2672/BookingAppSubServer/internal/utils"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// roomGuests is the party staying in one booked room.
+type roomGuests struct {
+	RoomId   uint `json:"roomId" binding:"required"`
+	Adults   uint `json:"adults" binding:"required,min=1,max=20"`
+	Children uint `json:"children" binding:"max=20"`
+}
+
+// defaultGuests are the guests of a booked room a request gives none for:
+// one adult, as recorded for the rooms booked before guests were.
+func defaultGuests(roomId uint) roomGuests {
+	return roomGuests{RoomId: roomId, Adults: 1}
+}
+
+// fits reports whether guests can stay in a room for at most maxAdults
+// adults and maxChildren children. Children may take the places of adults
+// but not the other way round.
+func (guests roomGuests) fits(maxAdults, maxChildren uint) bool {
+	return guests.Adults <= maxAdults && guests.Adults+guests.Children <= maxAdults+maxChildren
+}
+
+// roomTypeRequest books quantity rooms of a type, each for the same guests.
+type roomTypeRequest struct {
+	RoomTypeId uint `json:"roomTypeId" binding:"required"`
+	Quantity   uint `json:"quantity" binding:"required,min=1,max=20"`
+	Adults     uint `json:"adults" binding:"required,min=1,max=20"`
+	Children   uint `json:"children" binding:"max=20"`
+}
+
+// AvailabilityResponse lists the rooms without a type that are free for a
+// stay, and how many rooms of each type are. Rooms and room types whose
+// stay restrictions do not allow the stay are listed in Restricted.
+type AvailabilityResponse struct {
+	Rooms      []RoomResponse          `json:"rooms"`
+	RoomTypes  []AvailableRoomTypeInfo `json:"roomTypes"`
+	Restricted []RestrictedInfo        `json:"restricted"`
+}
+
+type RestrictedInfo struct {
+	RoomID     uint   `json:"roomId,omitempty"`
+	RoomTypeID uint   `json:"roomTypeId,omitempty"`
+	Code       string `json:"code"`
+	Message    string `json:"message"`
+}
+
+// restrictedInfo describes why err, returned by stayRules.check, keeps a
+// room or room type out of the search.
+func restrictedInfo(roomId, roomTypeId uint, err error) RestrictedInfo {
+	info := RestrictedInfo{RoomID: roomId, RoomTypeID: roomTypeId, Message: err.Error()}
+	var domainErr *domainError
+	if errors.As(err, &domainErr) {
+		info.Code = domainErr.code
+	}
+	return info
+}
+
+type AvailableRoomTypeInfo struct {
+	RoomTypeResponse
+	Available uint `json:"available"`
+}
+
+type availabilityRequest struct {
+	// Arrival and departure dates in the local time of the hotel
+	StartDate    Date `form:"startDate" binding:"required,date"`
+	EndDate      Date `form:"endDate" binding:"required,date"`
+	Adults       uint `form:"adults" binding:"required,min=1,max=20"`
+	Children     uint `form:"children" binding:"max=20"`
+	EarlyCheckIn bool `form:"earlyCheckIn"`
+	LateCheckOut bool `form:"lateCheckOut"`
+}
+
+// searchAvailableRooms lists the rooms and room types of a property that
+// are free for the whole stay and can each hold the party on their own.
+func (server *Server) searchAvailableRooms(ctx *gin.Context) {
+	propertyId, err := strconv.ParseUint(ctx.Param("propertyId"), 10, 64)
+	if err != nil {
+		respondInvalid(ctx, FieldError{Field: "propertyId", Rule: "id"})
+		return
+	}
+	var req availabilityRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		respondInvalid(ctx, err)
+		return
+	}
+	annotateSpan(ctx, attribute.Int64("property.id", int64(propertyId)))
+
+	property, err := server.store.GetProperty(ctx, uint(propertyId))
+	if errors.Is(err, db.ErrNotFound) {
+		err = errHotelNotFound
+	}
+	if err != nil {
+		respondErr(ctx, err)
+		return
+	}
+	if property.Status != utils.HotelStatusAvaiable {
+		respondErr(ctx, errHotelNotAvailable)
+		return
+	}
+
+	policy, err := server.stays.policy(property)
+	if err != nil {
+		respondInternalError(ctx, err)
+		return
+	}
+	if req.StartDate.Before(policy.today(time.Now()).Time) {
+		respondInvalid(ctx, FieldError{Field: "startDate", Rule: "notpast"})
+		return
+	}
+	stay, err := policy.stay(req.StartDate, req.EndDate, req.EarlyCheckIn, req.LateCheckOut)
+	if err != nil {
+		respondErr(ctx, err)
+		return
+	}
+	restrictions, err := server.store.ListStayRestrictions(ctx, property.Id)
+	if err != nil {
+		respondInternalError(ctx, err)
+		return
+	}
+	if err := rulesFor(restrictions, 0, 0).check(req.StartDate, req.EndDate); err != nil {
+		respondErr(ctx, err)
+		return
+	}
+	restricted := []RestrictedInfo{}
+
+	rooms, err := server.store.ListRoomsByProperties(ctx, []uint{property.Id}, "")
+	if err != nil {
+		respondInternalError(ctx, err)
+		return
+	}
+	party := roomGuests{Adults: req.Adults, Children: req.Children}
+	// Units are offered through their room type
+	rooms = slices.DeleteFunc(rooms, func(room db.T_Rooms) bool {
+		return room.Fk_Room_Type_Id != nil || room.Status != utils.RoomStatusAvaiable ||
+			!party.fits(room.Max_Adults, room.Max_Children)
+	})
+	roomIds := make([]uint, 0, len(rooms))
+	for _, room := range rooms {
+		roomIds = append(roomIds, room.Id)
+	}
+	booked, err := server.store.ListOverlappingRoomIds(ctx, roomIds, stay.start, stay.end)
+	if err != nil {
+		respondInternalError(ctx, err)
+		return
+	}
+	rooms = slices.DeleteFunc(rooms, func(room db.T_Rooms) bool {
+		if slices.Contains(booked, room.Id) {
+			return true
+		}
+		if err := rulesFor(restrictions, room.Id, 0).check(req.StartDate, req.EndDate); err != nil {
+			restricted = append(restricted, restrictedInfo(room.Id, 0, err))
+			return true
+		}
+		return false
+	})
+
+	roomResponses, err := server.buildRoomResponses(ctx, rooms)
+	if err != nil {
+		respondInternalError(ctx, err)
+		return
+	}
+
+	roomTypes, err := server.store.ListRoomTypesByProperties(ctx, []uint{property.Id})
+	if err != nil {
+		respondInternalError(ctx, err)
+		return
+	}
+	roomTypes = slices.DeleteFunc(roomTypes, func(roomType db.T_Room_Types) bool {
+		if roomType.Status != utils.RoomStatusAvaiable || !party.fits(roomType.Max_Adults, roomType.Max_Children) {
+			return true
+		}
+		if err := rulesFor(restrictions, 0, roomType.Id).check(req.StartDate, req.EndDate); err != nil {
+			restricted = append(restricted, restrictedInfo(0, roomType.Id, err))
+			return true
+		}
+		return false
+	})
+	available := map[uint]uint{}
+	for _, roomType := range roomTypes {
+		free, err := freeUnits(ctx, server.store, roomType.Id, stay, 0)
+		if err != nil {
+			respondInternalError(ctx, err)
+			return
+		}
+		available[roomType.Id] = free
+	}
+	roomTypes = slices.DeleteFunc(roomTypes, func(roomType db.T_Room_Types) bool {
+		return available[roomType.Id] == 0
+	})
+	roomTypeResponses, err := server.buildRoomTypeResponses(ctx, roomTypes)
+	if err != nil {
+		respondInternalError(ctx, err)
+		return
+	}
+
+	response := AvailabilityResponse{Rooms: roomResponses, RoomTypes: []AvailableRoomTypeInfo{}, Restricted: restricted}
+	for _, roomType := range roomTypeResponses {
+		response.RoomTypes = append(response.RoomTypes, AvailableRoomTypeInfo{
+			RoomTypeResponse: roomType,
+			Available:        available[roomType.ID],
+		})
+	}
+	respondOK(ctx, response)
+}
@@ -1,19 +1,24 @@
 package api
 
 import (
-	"fmt"
-	"io"
-	"net/http"
-	"os"
+	"errors"
+	"mime/multipart"
 	"strconv"
 	"time"
 
 	"github.com/lancer2672/BookingAppSubServer/db"
-	"github.com/lancer2672/BookingAppSubServer/internal/utils"
 
 	"github.com/gin-gonic/gin"
 )
 
+type bankAccountRequest struct {
+	BankName      string                `form:"bankName" binding:"required,max=100"`
+	AccountNumber string                `form:"accountNumber" binding:"required,numeric,max=30"`
+	AccountName   string                `form:"accountName" binding:"required,max=100"`
+	IsDefault     *bool                 `form:"isDefault" binding:"required"`
+	QRCode        *multipart.FileHeader `form:"qrCode"`
+}
+
 func (server *Server) updateBankAccount(ctx *gin.Context) {
 	// Parse bank account ID from path parameter
 	bankID := ctx.Param("bankId")
@@ -21,112 +26,70 @@ func (server *Server) updateBankAccount(ctx *gin.Context) {
 	// Check if bank ID is valid
 	id, err := strconv.Atoi(bankID)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bank account ID"})
+		respondInvalid(ctx, FieldError{Field: "bankId", Rule: "id"})
 		return
 	}
 
-	// Start a transaction
-	tx := server.store.Begin()
-
 	// Fetch the bank account to update
-	var bankAccount db.T_Banks
-	if err := tx.Where("id = ?", id).First(&bankAccount).Error; err != nil {
-		tx.Rollback()
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find bank account"})
+	bankAccount, err := server.store.GetBank(ctx, uint(id))
+	if errors.Is(err, db.ErrNotFound) {
+		err = errBankNotFound
+	}
+	if err != nil {
+		respondErr(ctx, err)
 		return
 	}
 
 	// Parse form data fields
-	bankName := ctx.PostForm("bankName")
-	accountNumber := ctx.PostForm("accountNumber")
-	accountName := ctx.PostForm("accountName")
-	isDefaultStr := ctx.PostForm("isDefault")
-	isDefault, err := strconv.ParseBool(isDefaultStr)
-	if err != nil {
-		tx.Rollback()
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid value for isDefault"})
+	var req bankAccountRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		respondInvalid(ctx, err)
 		return
 	}
+	isDefault := *req.IsDefault
 
 	// Handle QR Code (Image) upload
-	file, fileHeader, err := ctx.Request.FormFile("qrCode")
-	if err == nil {
-		defer file.Close()
-
-		// Customize upload path and file name as needed
-		filePath := fmt.Sprintf("uploads/%s", fileHeader.Filename)
-
-		// Save file to server
-		out, err := os.Create(filePath)
-		if err != nil {
-			tx.Rollback()
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save file"})
-			return
-		}
-		defer out.Close()
-
-		_, err = io.Copy(out, file)
+	if req.QRCode != nil {
+		qrCodeURL, err := server.saveUpload(ctx, req.QRCode)
 		if err != nil {
-			tx.Rollback()
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save file"})
+			respondUploadError(ctx, err)
 			return
 		}
-
-		fullPath := fmt.Sprintf("%s/%s", utils.URL_API, filePath)
-		qrCodeURL := &fullPath
-		bankAccount.QR_Code = qrCodeURL
-
+		bankAccount.QR_Code = &qrCodeURL
 	}
 
 	// Update bank account fields
-	bankAccount.Bank_Name = bankName
-	bankAccount.Account_Number = accountNumber
-	bankAccount.Account_Name = accountName
-
-	// Check if IsDefault is being updated
-	if isDefault {
-		// Set all other bank accounts of this agent to IsDefault = false
-		if err := tx.Model(&db.T_Banks{}).Where("fk_argent_id = ?", bankAccount.Fk_Argent_Id).
-			Where("id <> ?", id).
-			Update("is_default", false).Error; err != nil {
-			tx.Rollback()
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update other bank accounts"})
-			return
-		}
-	}
+	bankAccount.Bank_Name = req.BankName
+	bankAccount.Account_Number = req.AccountNumber
+	bankAccount.Account_Name = req.AccountName
 
 	// Update Is_Default field of the current bank account
 	bankAccount.Is_Default = isDefault
 
-	// Save changes to the database
-	if err := tx.Save(&bankAccount).Error; err != nil {
-		tx.Rollback()
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update bank account"})
+	err = server.store.ExecTx(ctx, func(store db.Store) error {
+		// Set all other bank accounts of this agent to IsDefault = false
+		if isDefault {
+			if err := store.ClearDefaultBank(ctx, bankAccount.Fk_Argent_Id, bankAccount.ID); err != nil {
+				return err
+			}
+		}
+
+		// Save changes to the database
+		return store.UpdateBank(ctx, &bankAccount)
+	})
+	if err != nil {
+		respondInternalError(ctx, err)
 		return
 	}
 
-	// Commit the transaction
-	tx.Commit()
-
-	ctx.JSON(http.StatusOK, gin.H{"message": "Bank account updated successfully"})
+	respondMessage(ctx, "Bank account updated successfully", nil)
 }
 
 func (server *Server) CreateBankAccount(ctx *gin.Context) {
 	// Parse form data
-	err := ctx.Request.ParseMultipartForm(10 << 20) // 10MB maximum form size
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Cannot parse form data"})
-		return
-	}
-
-	// Parse fields from form data
-	bankName := ctx.Request.FormValue("bankName")
-	accountNumber := ctx.Request.FormValue("accountNumber")
-	accountName := ctx.Request.FormValue("accountName")
-	isDefaultStr := ctx.Request.FormValue("isDefault")
-	isDefault, err := strconv.ParseBool(isDefaultStr)
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid value for isDefault"})
+	var req bankAccountRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		respondInvalid(ctx, err)
 		return
 	}
 
@@ -134,50 +97,34 @@ func (server *Server) CreateBankAccount(ctx *gin.Context) {
 	agentID := uint(1) // Replace with actual agent ID retrieval logic
 
 	// Handle QR Code (Image) upload
-	file, fileHeader, err := ctx.Request.FormFile("qrCode")
 	var qrCodeURL *string
-	if err == nil {
-		defer file.Close()
-
-		// Customize upload path and file name as needed
-		filePath := fmt.Sprintf("uploads/%s", fileHeader.Filename)
-
-		// Save file to server
-		out, err := os.Create(filePath)
-		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save file"})
-			return
-		}
-		defer out.Close()
-
-		_, err = io.Copy(out, file)
+	if req.QRCode != nil {
+		url, err := server.saveUpload(ctx, req.QRCode)
 		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save file"})
+			respondUploadError(ctx, err)
 			return
 		}
-
-		fullPath := fmt.Sprintf("%s/%s", utils.URL_API, *qrCodeURL)
-		qrCodeURL = &fullPath
+		qrCodeURL = &url
 	}
 
 	// Create bank account record
 	bankAccount := db.T_Banks{
-		Bank_Name:      bankName,
-		Account_Number: accountNumber,
+		Bank_Name:      req.BankName,
+		Account_Number: req.AccountNumber,
 		QR_Code:        qrCodeURL,
 		Fk_Argent_Id:   agentID,
-		Is_Default:     isDefault,
-		Account_Name:   accountName,
-		Create_At:      time.Now(),
+		Is_Default:     *req.IsDefault,
+		Account_Name:   req.AccountName,
+		Create_At:      time.Now().UTC(),
 	}
 
 	// Save bank account to database
-	if err := server.store.Create(&bankAccount).Error; err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create bank account"})
+	if err := server.store.CreateBank(ctx, &bankAccount); err != nil {
+		respondInternalError(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"message": "Bank account created successfully", "bankAccount": bankAccount})
+	respondMessage(ctx, "Bank account created successfully", bankAccount)
 }
 
 type BankResponse struct {
@@ -193,12 +140,16 @@ type BankResponse struct {
 
 func (server *Server) GetListAccountByAgentId(ctx *gin.Context) {
 	// Retrieve agent ID from token or any other method
-	agentID := ctx.Param("agentId")
+	agentID, err := strconv.ParseUint(ctx.Param("agentId"), 10, 64)
+	if err != nil {
+		respondInvalid(ctx, FieldError{Field: "agentId", Rule: "id"})
+		return
+	}
 
 	// Query bank accounts for the given agent ID
-	var bankAccounts = []db.T_Banks{}
-	if err := server.store.Where("fk_argent_id = ?", agentID).Find(&bankAccounts).Error; err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching bank accounts"})
+	bankAccounts, err := server.store.ListBanksByAgent(ctx, uint(agentID))
+	if err != nil {
+		respondInternalError(ctx, err)
 		return
 	}
 
@@ -212,11 +163,11 @@ func (server *Server) GetListAccountByAgentId(ctx *gin.Context) {
 			QRCode:        bank.QR_Code,
 			AgentID:       bank.Fk_Argent_Id,
 			IsDefault:     bank.Is_Default,
-			CreatedAt:     bank.Create_At,
+			CreatedAt:     bank.Create_At.UTC(),
 			AccountName:   bank.Account_Name,
 		}
 		bankResponses = append(bankResponses, bankResponse)
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"bankAccounts": bankResponses})
+	respondOK(ctx, bankResponses)
 }
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+// bankForm is the form of a bank account with the given number.
+func bankForm(accountNumber string, isDefault bool) map[string][]string {
+	return map[string][]string{
+		"bankName":      {"Vietcombank"},
+		"accountNumber": {accountNumber},
+		"accountName":   {"NGUYEN VAN A"},
+		"isDefault":     {fmt.Sprint(isDefault)},
+	}
+}
+
+func TestBankAccounts(t *testing.T) {
+	server, _ := newTestServer(t)
+
+	// Accounts are created for agent 1 until agents come from the token
+	for _, form := range []map[string][]string{bankForm("0011001", true), bankForm("0011002", false)} {
+		if status, response := serveForm(t, server, http.MethodPost, "/api/banks/", form); status != http.StatusOK {
+			t.Fatalf("create: got %d %+v", status, response.Error)
+		}
+	}
+	status, response := serveJSON(t, server, http.MethodGet, "/api/banks/1", nil)
+	if status != http.StatusOK {
+		t.Fatalf("list: got %d %+v", status, response.Error)
+	}
+	var banks []BankResponse
+	decodeData(t, response, &banks)
+	if len(banks) != 2 {
+		t.Fatalf("list: got %+v", banks)
+	}
+
+	// Making an account the default clears the previous one
+	second := banks[1]
+	status, response = serveForm(t, server, http.MethodPut, fmt.Sprintf("/api/banks/%d", second.ID), bankForm(second.AccountNumber, true))
+	if status != http.StatusOK {
+		t.Fatalf("update: got %d %+v", status, response.Error)
+	}
+	_, response = serveJSON(t, server, http.MethodGet, "/api/banks/1", nil)
+	decodeData(t, response, &banks)
+	for _, bank := range banks {
+		if bank.IsDefault != (bank.ID == second.ID) {
+			t.Fatalf("after update: got %+v", banks)
+		}
+	}
+}
+
+func TestBankAccountsInvalid(t *testing.T) {
+	server, _ := newTestServer(t)
+
+	status, response := serveForm(t, server, http.MethodPost, "/api/banks/", bankForm("not a number", true))
+	expectError(t, status, response, http.StatusBadRequest, CodeValidationFailed)
+
+	status, response = serveForm(t, server, http.MethodPut, "/api/banks/999", bankForm("0011001", true))
+	expectError(t, status, response, http.StatusNotFound, CodeBankAccountNotFound)
+
+	status, response = serveJSON(t, server, http.MethodGet, "/api/banks/abc", nil)
+	expectError(t, status, response, http.StatusBadRequest, CodeValidationFailed)
+}
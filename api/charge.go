@@ -0,0 +1,189 @@
+package api
+
+import (
+	"errors"
+	"math"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lancer2672/BookingAppSubServer/db"
+	"github.com/lancer2672/BookingAppSubServer/internal/utils"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type createChargeRequest struct {
+	PropertyId uint   `json:"propertyId" binding:"required"`
+	Name       string `json:"name" binding:"required,max=100"`
+	Kind       string `json:"kind" binding:"required,oneof=TAX FEE"`
+	// Percent of the room price after discount, or amount for each room
+	// and night
+	Basis string  `json:"basis" binding:"required,oneof=PERCENTAGE PER_NIGHT"`
+	Value float64 `json:"value" binding:"required,gt=0"`
+	// Set when room prices already include the charge
+	Inclusive bool `json:"inclusive"`
+}
+
+type ChargeResponse struct {
+	Id         uint    `json:"id"`
+	PropertyId uint    `json:"propertyId"`
+	Name       string  `json:"name"`
+	Kind       string  `json:"kind"`
+	Basis      string  `json:"basis"`
+	Value      float64 `json:"value"`
+	Inclusive  bool    `json:"inclusive"`
+}
+
+func newChargeResponse(charge db.T_Property_Charges) ChargeResponse {
+	return ChargeResponse{
+		Id:         charge.Id,
+		PropertyId: charge.Fk_Property_Id,
+		Name:       charge.Name,
+		Kind:       charge.Kind,
+		Basis:      charge.Basis,
+		Value:      charge.Value,
+		Inclusive:  charge.Inclusive,
+	}
+}
+
+func (server *Server) createCharge(ctx *gin.Context) {
+	var req createChargeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondInvalid(ctx, err)
+		return
+	}
+	annotateSpan(ctx, attribute.Int64("property.id", int64(req.PropertyId)))
+
+	charge := db.T_Property_Charges{
+		Fk_Property_Id: req.PropertyId,
+		Name:           req.Name,
+		Kind:           req.Kind,
+		Basis:          req.Basis,
+		Value:          req.Value,
+		Inclusive:      req.Inclusive,
+	}
+	err := server.store.ExecTx(ctx, func(store db.Store) error {
+		if _, err := store.GetProperty(ctx, req.PropertyId); err != nil {
+			if errors.Is(err, db.ErrNotFound) {
+				return errHotelNotFound
+			}
+			return err
+		}
+		return store.CreatePropertyCharge(ctx, &charge)
+	})
+	if err != nil {
+		respondErr(ctx, err)
+		return
+	}
+
+	respondOK(ctx, newChargeResponse(charge))
+}
+
+func (server *Server) getCharges(ctx *gin.Context) {
+	propertyId, err := strconv.ParseUint(ctx.Param("propertyId"), 10, 64)
+	if err != nil {
+		respondInvalid(ctx, FieldError{Field: "propertyId", Rule: "id"})
+		return
+	}
+	annotateSpan(ctx, attribute.Int64("property.id", int64(propertyId)))
+
+	charges, err := server.store.ListPropertyCharges(ctx, uint(propertyId))
+	if err != nil {
+		respondInternalError(ctx, err)
+		return
+	}
+	responses := []ChargeResponse{}
+	for _, charge := range charges {
+		responses = append(responses, newChargeResponse(charge))
+	}
+	respondOK(ctx, responses)
+}
+
+func (server *Server) deleteCharge(ctx *gin.Context) {
+	chargeId, err := strconv.ParseUint(ctx.Param("chargeId"), 10, 64)
+	if err != nil {
+		respondInvalid(ctx, FieldError{Field: "chargeId", Rule: "id"})
+		return
+	}
+
+	if err := server.store.DeletePropertyCharge(ctx, uint(chargeId)); err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			err = errChargeNotFound
+		}
+		respondErr(ctx, err)
+		return
+	}
+
+	respondMessage(ctx, "Tax or fee deleted successfully", nil)
+}
+
+// roundPrice rounds an amount to hundredths.
+func roundPrice(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// repriceLineItems prices the line items of a booking again for a changed
+// stay, keeping the rates they were booked at.
+func repriceLineItems(items []db.T_Booking_Line_Items, price float64, rooms, nights int) ([]db.T_Booking_Line_Items, float64) {
+	charges := make([]db.T_Property_Charges, len(items))
+	for i, item := range items {
+		charges[i] = db.T_Property_Charges{
+			Name:      item.Name,
+			Kind:      item.Kind,
+			Basis:     item.Basis,
+			Value:     item.Value,
+			Inclusive: item.Inclusive,
+		}
+	}
+	priced, exclusive := priceCharges(charges, price, rooms, nights)
+	for i := range priced {
+		priced[i].Fk_Property_Charge_Id = items[i].Fk_Property_Charge_Id
+	}
+	return priced, exclusive
+}
+
+// priceCharges returns the line items of charges on a stay of nights in
+// rooms rooms whose price after discount is price, and the sum of the
+// exclusive ones, which the guest pays on top of price. Inclusive
+// percentages are taken out of price together, as it holds all of them,
+// after the inclusive amounts for each room and night.
+func priceCharges(charges []db.T_Property_Charges, price float64, rooms, nights int) ([]db.T_Booking_Line_Items, float64) {
+	inclusiveRate, inclusiveFixed := 0.0, 0.0
+	for _, charge := range charges {
+		switch {
+		case !charge.Inclusive:
+		case charge.Basis == utils.ChargeBasis_PerNight:
+			inclusiveFixed += roundPrice(charge.Value * float64(rooms) * float64(nights))
+		default:
+			inclusiveRate += charge.Value
+		}
+	}
+	net := math.Max(price-inclusiveFixed, 0) / (1 + inclusiveRate/100)
+
+	var items []db.T_Booking_Line_Items
+	var exclusive float64
+	for _, charge := range charges {
+		var amount float64
+		switch {
+		case charge.Basis == utils.ChargeBasis_PerNight:
+			amount = charge.Value * float64(rooms) * float64(nights)
+		case charge.Inclusive:
+			amount = net * charge.Value / 100
+		default:
+			amount = price * charge.Value / 100
+		}
+		amount = roundPrice(amount)
+		if !charge.Inclusive {
+			exclusive += amount
+		}
+		items = append(items, db.T_Booking_Line_Items{
+			Fk_Property_Charge_Id: &charge.Id,
+			Name:                  charge.Name,
+			Kind:                  charge.Kind,
+			Basis:                 charge.Basis,
+			Value:                 charge.Value,
+			Inclusive:             charge.Inclusive,
+			Amount:                amount,
+		})
+	}
+	return items, exclusive
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestBookingInclusiveCharges(t *testing.T) {
+	server, store := newTestServer(t)
+	property := createTestProperty(t, store, 7)
+	room := createTestRoom(t, store, property.Id, 500)
+
+	for _, charge := range []map[string]any{
+		{"name": "VAT", "kind": "TAX", "basis": "PERCENTAGE", "value": 10, "inclusive": true},
+		{"name": "City tax", "kind": "TAX", "basis": "PER_NIGHT", "value": 10, "inclusive": true},
+		{"name": "Cleaning", "kind": "FEE", "basis": "PER_NIGHT", "value": 5},
+	} {
+		charge["propertyId"] = property.Id
+		status, response := serveJSON(t, server, http.MethodPost, "/api/charges", charge)
+		if status != http.StatusOK {
+			t.Fatalf("create charge %v: got %d %+v", charge["name"], status, response.Error)
+		}
+	}
+
+	status, response := bookRoom(t, server, room, 10, 2)
+	if status != http.StatusOK {
+		t.Fatalf("create: got %d %+v", status, response.Error)
+	}
+	var booking BookingResponse
+	decodeData(t, response, &booking)
+
+	status, response = serveJSON(t, server, http.MethodGet, fmt.Sprintf("/api/bookings/%d", booking.Id), nil)
+	if status != http.StatusOK {
+		t.Fatalf("get: got %d %+v", status, response.Error)
+	}
+	var detail BookingDetailResponse
+	decodeData(t, response, &detail)
+
+	// The room price of 1000 holds the city tax of 20 and the VAT on the
+	// remaining 980; only the cleaning fee is added on top
+	amounts := map[string]float64{}
+	for _, charge := range detail.Price.Charges {
+		amounts[charge.Name] = charge.Amount
+	}
+	want := map[string]float64{"VAT": 89.09, "City tax": 20, "Cleaning": 10}
+	for name, amount := range want {
+		if amounts[name] != amount {
+			t.Fatalf("charges: got %+v, want %+v", amounts, want)
+		}
+	}
+	if detail.Price.Subtotal != 1000 || detail.Price.Total != 1010 || detail.Total_Price != 1010 {
+		t.Fatalf("price: got %+v total %v", detail.Price, detail.Total_Price)
+	}
+}
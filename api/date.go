@@ -0,0 +1,67 @@
+package api
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// Date is a calendar date without a time zone, such as the arrival date of
+// a stay, which is only meaningful in the local time of its property. It is
+// written as "2006-01-02" in JSON. RFC 3339 timestamps are accepted too,
+// keeping the date they were written with, for clients of the older API.
+type Date struct {
+	// Midnight UTC of the date
+	time.Time
+	// invalid holds text that is not a date, so the date validation rule
+	// can report it against the right field
+	invalid string
+}
+
+var dateType = reflect.TypeOf(Date{})
+
+// dateOf returns the calendar date of t in its own location.
+func dateOf(t time.Time) Date {
+	year, month, day := t.Date()
+	return Date{Time: time.Date(year, month, day, 0, 0, 0, 0, time.UTC)}
+}
+
+func (d Date) String() string {
+	return d.Format(time.DateOnly)
+}
+
+func (d Date) MarshalJSON() ([]byte, error) {
+	if d.IsZero() {
+		return []byte("null"), nil
+	}
+	return []byte(strconv.Quote(d.String())), nil
+}
+
+func (d *Date) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	value, err := strconv.Unquote(string(data))
+	if err != nil {
+		return &json.UnmarshalTypeError{Value: "non-string", Type: dateType}
+	}
+	*d = parseDate(value)
+	return nil
+}
+
+// UnmarshalParam reads a date from a query or form parameter.
+func (d *Date) UnmarshalParam(param string) error {
+	*d = parseDate(param)
+	return nil
+}
+
+func parseDate(value string) Date {
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return Date{Time: t}
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return dateOf(t)
+	}
+	return Date{invalid: value}
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// domainError is an expected failure caused by the request rather than by
+// the server, such as booking a room that does not exist. respondErr writes
+// it with its own status so only real outages show up as 500.
+type domainError struct {
+	status  int
+	code    string
+	message string
+}
+
+func (e *domainError) Error() string {
+	return e.message
+}
+
+// notFoundError is for resources the request refers to that do not exist.
+func notFoundError(code, message string) *domainError {
+	return &domainError{status: http.StatusNotFound, code: code, message: message}
+}
+
+// conflictError is for requests that clash with the current state of a
+// resource, usually because of another request.
+func conflictError(code, message string) *domainError {
+	return &domainError{status: http.StatusConflict, code: code, message: message}
+}
+
+// unprocessableError is for well-formed requests that break a business rule.
+func unprocessableError(code, message string) *domainError {
+	return &domainError{status: http.StatusUnprocessableEntity, code: code, message: message}
+}
+
+var (
+	errBookingNotFound   = notFoundError(CodeBookingNotFound, "Booking not found")
+	errRoomNotFound      = notFoundError(CodeRoomNotFound, "Room not found")
+	errHotelNotFound     = notFoundError(CodeHotelNotFound, "Hotel not found")
+	errBankNotFound      = notFoundError(CodeBankAccountNotFound, "Bank account not found")
+	errRoomAlreadyBooked = conflictError(CodeRoomAlreadyBooked, "Room already booked within this time frame")
+	errRoomNotAvailable  = unprocessableError(CodeRoomNotAvailable, "Room is not available")
+	errHotelNotAvailable = unprocessableError(CodeHotelNotAvailable, "Hotel is not available")
+	errRoomNotInHotel    = unprocessableError(CodeRoomNotInHotel, "Room does not belong to the hotel")
+	errRoomNotInBooking  = unprocessableError(CodeRoomNotInBooking, "Room is not part of the booking")
+	errRoomOverOccupied  = unprocessableError(CodeRoomOverOccupied, "Too many guests for the room")
+	errRoomTypeNotFound  = notFoundError(CodeRoomTypeNotFound, "Room type not found")
+	errRoomTypeSoldOut   = conflictError(CodeRoomTypeSoldOut, "Not enough rooms of this type left for the stay")
+	errRoomBookedByType  = unprocessableError(CodeRoomBookedByType, "Room is booked through its room type")
+	errUnitNotNeeded     = unprocessableError(CodeUnitNotNeeded, "Room is not a unit of a room type the booking still needs")
+	errNoFreeUnit        = conflictError(CodeNoFreeUnit, "No room of the booked type is free, assign one manually")
+
+	errStayRestrictionNotFound = notFoundError(CodeStayRestrictionNotFound, "Stay restriction not found")
+
+	errPromotionNotFound  = notFoundError(CodePromotionNotFound, "Promotion code not found")
+	errPromotionCodeTaken = conflictError(CodePromotionCodeTaken, "Promotion code is already in use")
+	errPromotionRedeemed  = conflictError(CodePromotionRedeemed, "Promotion code has been fully redeemed")
+	errHotelNotOfAgent    = unprocessableError(CodeHotelNotOfAgent, "Hotel does not belong to the agent")
+
+	errChargeNotFound = notFoundError(CodeChargeNotFound, "Tax or fee not found")
+
+	errBookingNotModifiable = unprocessableError(CodeBookingNotModifiable, "Only pending or confirmed bookings can be changed")
+
+	errEarlyCheckInNotOffered = unprocessableError(CodeEarlyCheckInNotOffered, "Hotel does not offer early check-in")
+	errLateCheckOutNotOffered = unprocessableError(CodeLateCheckOutNotOffered, "Hotel does not offer late check-out")
+)
+
+// isConflict reports whether err is a domain error answered with 409.
+func isConflict(err error) bool {
+	var domainErr *domainError
+	return errors.As(err, &domainErr) && domainErr.status == http.StatusConflict
+}
+
+// respondErr writes err as a domain error when it is one, as a validation
+// error when it is a FieldError, and as an internal error otherwise.
+func respondErr(ctx *gin.Context, err error) {
+	var domainErr *domainError
+	if errors.As(err, &domainErr) {
+		respondError(ctx, domainErr.status, domainErr.code, domainErr.message)
+		return
+	}
+	var fieldErr FieldError
+	if errors.As(err, &fieldErr) {
+		respondInvalid(ctx, fieldErr)
+		return
+	}
+	respondInternalError(ctx, err)
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// healthCheckTimeout bounds each readiness check so a hanging dependency
+// cannot hang the probe.
+const healthCheckTimeout = 2 * time.Second
+
+const (
+	healthStatusOK       = "ok"
+	healthStatusDegraded = "degraded"
+)
+
+type HealthCheck struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	// Duration of the check in milliseconds
+	Duration int64 `json:"durationMs"`
+}
+
+type HealthReport struct {
+	Status string                 `json:"status"`
+	Checks map[string]HealthCheck `json:"checks,omitempty"`
+}
+
+// livez only tells whether the process is up and serving HTTP. It checks no
+// dependency, so a database outage never gets the instance restarted.
+func (server *Server) livez(ctx *gin.Context) {
+	respondOK(ctx, HealthReport{Status: healthStatusOK})
+}
+
+// healthcheck is the probe that predates livez. It keeps answering with
+// the bare "OK" string its clients expect.
+func (server *Server) healthcheck(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, "OK")
+}
+
+// readyz tells whether the instance should receive traffic: the database
+// answers and uploads can be stored. It answers 503 with the failing checks
+// otherwise.
+func (server *Server) readyz(ctx *gin.Context) {
+	checks := map[string]func(context.Context) error{
+		"database": server.store.Ping,
+		"storage":  server.storage.Check,
+	}
+
+	report := HealthReport{Status: healthStatusOK, Checks: map[string]HealthCheck{}}
+	for name, check := range checks {
+		checkCtx, cancel := context.WithTimeout(ctx.Request.Context(), healthCheckTimeout)
+		start := time.Now()
+		err := check(checkCtx)
+		cancel()
+
+		result := HealthCheck{Status: healthStatusOK, Duration: time.Since(start).Milliseconds()}
+		if err != nil {
+			result.Status = healthStatusDegraded
+			result.Error = err.Error()
+			report.Status = healthStatusDegraded
+		}
+		report.Checks[name] = result
+	}
+
+	status := http.StatusOK
+	if report.Status != healthStatusOK {
+		status = http.StatusServiceUnavailable
+	}
+	ctx.JSON(status, Response{Data: report})
+}
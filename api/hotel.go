@@ -2,427 +2,651 @@ package api
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
-	"log"
+	"mime/multipart"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/lancer2672/BookingAppSubServer/db"
 
 	"github.com/gin-gonic/gin"
 	"github.com/lancer2672/BookingAppSubServer/internal/utils"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type bookingRequest struct {
 	// TODO: Retrieve from token
-	UserId     uint      `form:"userId" "`
-	RoomIds    []uint    `form:"roomIds" "`
-	PropertyId uint      `form:"propertyId" "`
-	StartDate  time.Time `form:"startDate" "`
-	EndDate    time.Time `form:"endDate" "`
-	Deposit    float64   `form:"deposit"`
-}
-type bookingRequestv2 struct {
-	UserId     uint      `json:"userId"`
-	RoomIds    []uint    `json:"roomIds"`
-	PropertyId uint      `json:"propertyId"`
-	StartDate  time.Time `json:"startDate"`
-	EndDate    time.Time `json:"endDate"`
-	Deposit    float64   `json:"deposit"`
+	UserId uint `json:"userId" binding:"required"`
+	// Rooms without a type, booked individually
+	RoomIds []uint `json:"roomIds" binding:"max=20,unique,dive,required"`
+	// The guests staying in rooms of RoomIds; a room left out has one adult
+	Guests     []roomGuests      `json:"guests" binding:"max=20,unique=RoomId,dive"`
+	RoomTypes  []roomTypeRequest `json:"roomTypes" binding:"max=10,unique=RoomTypeId,dive"`
+	PropertyId uint              `json:"propertyId" binding:"required"`
+	// Arrival and departure dates in the local time of the hotel; the stay
+	// runs from its check-in time to its check-out time
+	StartDate    Date    `json:"startDate" binding:"required,date"`
+	EndDate      Date    `json:"endDate" binding:"required,date"`
+	Deposit      float64 `json:"deposit" binding:"min=0"`
+	EarlyCheckIn bool    `json:"earlyCheckIn"`
+	LateCheckOut bool    `json:"lateCheckOut"`
+	PromoCode    string  `json:"promoCode" binding:"max=32"`
 }
 
 func (server *Server) createBookingV2(ctx *gin.Context) {
 	var req bookingRequest
 
-	log.Println(">>>CreateBookingV2")
 	// Parse JSON body
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		log.Println(">>>CreateBookingV2 1 ", err)
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		respondInvalid(ctx, err)
 		return
 	}
+	setRequestUser(ctx, req.UserId)
+	roomCount := len(req.RoomIds)
+	for _, roomType := range req.RoomTypes {
+		roomCount += int(roomType.Quantity)
+	}
+	annotateSpan(ctx,
+		attribute.Int64("property.id", int64(req.PropertyId)),
+		attribute.Int("booking.room_count", roomCount),
+	)
 
-	// Start a transaction
-	tx := server.store.Begin()
-	log.Println(">>>CreateBookingV2 1 ")
-
-	var totalPrice float64 = 0
-	// Iterate over each room ID to check availability and calculate the total price
-	for _, roomId := range req.RoomIds {
-		var room db.T_Rooms
-		if err := tx.Where("id = ?", roomId).First(&room).Error; err != nil {
-			tx.Rollback()
-			log.Println(">>>CreateBookingV2 2 ", err)
-			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
-			return
+	var booking db.T_Bookings
+	err := server.store.ExecTx(ctx, func(store db.Store) error {
+		property, err := store.GetProperty(ctx, req.PropertyId)
+		if errors.Is(err, db.ErrNotFound) {
+			return errHotelNotFound
 		}
-		// Check room availability within the requested time frame
-		var overlappingBookings []db.T_Bookings
-		if err := tx.Joins("JOIN t_booking_rooms ON t_booking_rooms.fk_booking_id = t_bookings.id").
-			Where("t_booking_rooms.fk_room_id = ? AND ((t_bookings.start_date, t_bookings.end_date) OVERLAPS (?, ?))", room.Id, req.StartDate, req.EndDate).
-			Find(&overlappingBookings).Error; err != nil {
-			tx.Rollback()
-			log.Println(">>>CreateBookingV2 3 ", err)
-			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
-			return
+		if err != nil {
+			return err
 		}
 
-		// Handle overlapping booking scenario
-		if len(overlappingBookings) > 0 {
-			tx.Rollback()
-			ctx.JSON(http.StatusConflict, gin.H{"error": "Room already booked within this time frame"})
-			return
+		if property.Status != utils.HotelStatusAvaiable {
+			return errHotelNotAvailable
 		}
 
-		if room.Status != utils.RoomStatusAvaiable {
-			tx.Rollback()
-			log.Println(">>>CreateBookingV2 4 ", room.Status)
-			ctx.JSON(http.StatusInternalServerError, errorResponse(fmt.Errorf("room %d not available", roomId)))
-			return
+		policy, err := server.stays.policy(property)
+		if err != nil {
+			return err
+		}
+		if req.StartDate.Before(policy.today(time.Now()).Time) {
+			return FieldError{Field: "startDate", Rule: "notpast"}
+		}
+		stay, err := policy.stay(req.StartDate, req.EndDate, req.EarlyCheckIn, req.LateCheckOut)
+		if err != nil {
+			return err
+		}
+		restrictions, err := store.ListStayRestrictions(ctx, property.Id)
+		if err != nil {
+			return err
+		}
+		if err := rulesFor(restrictions, 0, 0).check(req.StartDate, req.EndDate); err != nil {
+			return err
 		}
 
-		duration := req.EndDate.Sub(req.StartDate).Hours() / 24
-		totalPrice += float64(room.Price) * duration
-	}
-
-	var property db.T_Properties
-	if err := tx.Where("id = ?", req.PropertyId).First(&property).Error; err != nil {
-		tx.Rollback()
-		log.Println(">>>CreateBookingV2 5 ", err)
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
-		return
-	}
+		guestsByRoom := map[uint]roomGuests{}
+		for _, guests := range req.Guests {
+			guestsByRoom[guests.RoomId] = guests
+		}
 
-	if property.Status != utils.HotelStatusAvaiable {
-		tx.Rollback()
-		log.Println(">>>CreateBookingV2 6 hotel not available")
-		ctx.JSON(http.StatusInternalServerError, errorResponse(fmt.Errorf("hotel not available")))
-		return
-	}
+		var lines []priceLine
+		var bookingRooms []db.T_Booking_Rooms
+		// Iterate over each room ID to check availability and calculate the total price
+		for _, roomId := range req.RoomIds {
+			room, err := store.GetRoom(ctx, roomId)
+			if errors.Is(err, db.ErrNotFound) {
+				return errRoomNotFound
+			}
+			if err != nil {
+				return err
+			}
+			// The booking is priced and listed under property, so every
+			// room must be one of its rooms
+			if room.Fk_Property_Id != property.Id {
+				return errRoomNotInHotel
+			}
+			if room.Fk_Room_Type_Id != nil {
+				return errRoomBookedByType
+			}
+			if err := rulesFor(restrictions, room.Id, 0).check(req.StartDate, req.EndDate); err != nil {
+				return err
+			}
+			// Check room availability within the requested time frame
+			overlapping, err := store.HasOverlappingBooking(ctx, room.Id, stay.start, stay.end, 0)
+			if err != nil {
+				return err
+			}
 
-	var status = utils.BookingStatus_Confirmed
-	if req.Deposit != 0 {
-		status = utils.BookingStatus_Pending
-	}
-	booking := db.T_Bookings{
-		Fk_User_Id:     req.UserId,
-		Status:         status,
-		Start_Date:     req.StartDate,
-		End_Date:       req.EndDate,
-		Create_At:      time.Now(),
-		Fk_Property_Id: property.Id,
-		Total_Price:    totalPrice,
-	}
+			// Handle overlapping booking scenario
+			if overlapping {
+				return errRoomAlreadyBooked
+			}
 
-	if err := tx.Create(&booking).Error; err != nil {
-		log.Println(">>>CreateBookingV2 7", err)
-		tx.Rollback()
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
-		return
-	}
-	for _, roomId := range req.RoomIds {
-		bookingRoom := &db.T_Booking_Rooms{
-			Fk_Room_Id:    roomId,
-			Fk_Booking_id: booking.Id,
-		}
-		if err := tx.Create(&bookingRoom).Error; err != nil {
-			log.Println(">>>CreateBookingV2 8", err)
-			tx.Rollback()
-			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
-			return
-		}
-	}
+			if room.Status != utils.RoomStatusAvaiable {
+				return errRoomNotAvailable
+			}
+			guests, ok := guestsByRoom[roomId]
+			if !ok {
+				guests = defaultGuests(roomId)
+			}
+			if !guests.fits(room.Max_Adults, room.Max_Children) {
+				return errRoomOverOccupied
+			}
 
-	// Create booking deposit record if deposit is provided
-	if req.Deposit != 0 {
-		deposit := db.T_Booking_Deposits{
-			Fk_Booking_ID: booking.Id,
-			Deposit:       req.Deposit,
-		}
-		if err := tx.Create(&deposit).Error; err != nil {
-			log.Println(">>>CreateBookingV2 9", err)
-			tx.Rollback()
-			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
-			return
+			line := priceLine{roomId: room.Id, amount: float64(room.Price) * float64(stay.nights)}
+			lines = append(lines, line)
+			bookingRooms = append(bookingRooms, db.T_Booking_Rooms{
+				Fk_Room_Id: roomId,
+				Adults:     guests.Adults,
+				Children:   guests.Children,
+				Amount:     line.amount,
+			})
 		}
-	}
 
-	// Commit the transaction
-	tx.Commit()
+		// Rooms booked by type get their units at check-in; locking the type
+		// keeps concurrent bookings from taking the same free rooms
+		var bookingRoomTypes []db.T_Booking_Room_Types
+		for _, line := range req.RoomTypes {
+			roomType, err := store.LockRoomType(ctx, line.RoomTypeId)
+			if errors.Is(err, db.ErrNotFound) {
+				return errRoomTypeNotFound
+			}
+			if err != nil {
+				return err
+			}
+			if roomType.Fk_Property_Id != property.Id {
+				return errRoomNotInHotel
+			}
+			if err := rulesFor(restrictions, 0, roomType.Id).check(req.StartDate, req.EndDate); err != nil {
+				return err
+			}
+			guests := roomGuests{Adults: line.Adults, Children: line.Children}
+			if !guests.fits(roomType.Max_Adults, roomType.Max_Children) {
+				return errRoomOverOccupied
+			}
+			if err := checkRoomTypeAvailable(ctx, store, roomType, line.Quantity, stay, 0); err != nil {
+				return err
+			}
 
-	ctx.JSON(http.StatusOK, booking)
-}
+			price := priceLine{
+				roomTypeId: roomType.Id,
+				amount:     float64(roomType.Price) * float64(stay.nights) * float64(line.Quantity),
+			}
+			lines = append(lines, price)
+			bookingRoomTypes = append(bookingRoomTypes, db.T_Booking_Room_Types{
+				Fk_Room_Type_Id: roomType.Id,
+				Quantity:        line.Quantity,
+				Adults:          line.Adults,
+				Children:        line.Children,
+				Amount:          price.amount,
+			})
+		}
 
-type updateStatusRequest struct {
-	BookingId uint   `json:"bookingId"`
-	Status    string `json:"status"`
-}
+		// The promotion row stays locked until the booking is committed, so
+		// concurrent bookings cannot redeem a code beyond its limit
+		var discount float64
+		var promotionId *uint
+		if req.PromoCode != "" {
+			promotion, err := store.LockPromotionByCode(ctx, promotionCode(req.PromoCode))
+			if errors.Is(err, db.ErrNotFound) {
+				return errPromotionNotFound
+			}
+			if err != nil {
+				return err
+			}
+			if promotion.Max_Redemptions != 0 && promotion.Redemptions >= promotion.Max_Redemptions {
+				return errPromotionRedeemed
+			}
+			rules, err := loadPromotion(ctx, store, promotion)
+			if err != nil {
+				return err
+			}
+			discount, err = rules.discount(property, req.StartDate, stay.nights, lines)
+			if err != nil {
+				return err
+			}
+			if err := store.RedeemPromotion(ctx, promotion.Id); err != nil {
+				return err
+			}
+			promotionId = &promotion.Id
+		}
+		charges, err := store.ListPropertyCharges(ctx, property.Id)
+		if err != nil {
+			return err
+		}
+		rooms := len(bookingRooms)
+		for _, line := range bookingRoomTypes {
+			rooms += int(line.Quantity)
+		}
+		lineItems, exclusive := priceCharges(charges, subtotal(lines)-discount, rooms, stay.nights)
 
-func (server *Server) createBooking(ctx *gin.Context) {
-	var req bookingRequest
+		var status = utils.BookingStatus_Confirmed
+		if req.Deposit != 0 {
+			status = utils.BookingStatus_Pending
+		}
+		booking = db.T_Bookings{
+			Fk_User_Id:      req.UserId,
+			Status:          status,
+			Start_Date:      stay.start,
+			End_Date:        stay.end,
+			Create_At:       time.Now().UTC(),
+			Fk_Property_Id:  property.Id,
+			Subtotal_Price:  subtotal(lines),
+			Discount:        discount,
+			Total_Price:     subtotal(lines) - discount + exclusive,
+			Fk_Promotion_Id: promotionId,
+			Nights:          stay.nights,
+			Early_Check_In:  req.EarlyCheckIn,
+			Late_Check_Out:  req.LateCheckOut,
+		}
 
-	log.Println(">>>CreateBooking")
-	// Parse form data
-	if err := ctx.ShouldBind(&req); err != nil {
-		log.Println(">>>CreateBooking 1 ", err)
+		if err := store.CreateBooking(ctx, &booking); err != nil {
+			return err
+		}
+		for i := range bookingRooms {
+			bookingRooms[i].Fk_Booking_id = booking.Id
+		}
+		if err := store.AddBookingRooms(ctx, bookingRooms); err != nil {
+			return err
+		}
+		for i := range bookingRoomTypes {
+			bookingRoomTypes[i].Fk_Booking_Id = booking.Id
+		}
+		if err := store.AddBookingRoomTypes(ctx, bookingRoomTypes); err != nil {
+			return err
+		}
+		if err := store.SetBookingLineItems(ctx, booking.Id, lineItems); err != nil {
+			return err
+		}
+		if err := store.AddBookingStatusHistory(ctx, &db.T_Booking_Status_Histories{
+			Fk_Booking_Id: booking.Id,
+			To_Status:     booking.Status,
+			Create_At:     booking.Create_At,
+		}); err != nil {
+			return err
+		}
 
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		// Create booking deposit record if deposit is provided
+		if req.Deposit != 0 {
+			return store.CreateBookingDeposit(ctx, &db.T_Booking_Deposits{
+				Fk_Booking_ID: booking.Id,
+				Deposit:       req.Deposit,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		if isConflict(err) {
+			server.metrics.bookingConflicts.Inc()
+		}
+		respondErr(ctx, err)
 		return
 	}
 
-	// Start a transaction
-	tx := server.store.Begin()
-	log.Println(">>>CreateBooking 1 ")
-
-	var totalPrice float64 = 0
-	// Iterate over each room ID to check availability and calculate the total price
-	for _, roomId := range req.RoomIds {
-		var room db.T_Rooms
-		if err := tx.Where("id = ?", roomId).First(&room).Error; err != nil {
-			tx.Rollback()
-			log.Println(">>>CreateBooking 2 ", err)
+	annotateSpan(ctx, attribute.Int64("booking.id", int64(booking.Id)))
+	server.metrics.bookingsCreated.WithLabelValues(booking.Status).Inc()
+	if req.Deposit != 0 {
+		server.metrics.depositsCreated.Inc()
+	}
+	respondOK(ctx, inUTC(booking))
+}
 
-			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
-			return
-		}
-		// Check room availability within the requested time frame
-		var overlappingBookings []db.T_Bookings
-		if err := tx.Joins("JOIN t_booking_rooms ON t_booking_rooms.fk_booking_id = t_bookings.id").
-			Where("t_booking_rooms.fk_room_id = ? AND ((t_bookings.start_date, t_bookings.end_date) OVERLAPS (?, ?))", room.Id, req.StartDate, req.EndDate).
-			Find(&overlappingBookings).Error; err != nil {
-			tx.Rollback()
-			log.Println(">>>CreateBooking 3 ", err)
+// inUTC returns booking with its instants in UTC, whatever the time zone
+// they were read from the database in.
+func inUTC(booking db.T_Bookings) db.T_Bookings {
+	booking.Start_Date = booking.Start_Date.UTC()
+	booking.End_Date = booking.End_Date.UTC()
+	booking.Create_At = booking.Create_At.UTC()
+	return booking
+}
 
-			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
-			return
-		}
+type updateStatusRequest struct {
+	BookingId uint   `json:"bookingId" binding:"required"`
+	Status    string `json:"status" binding:"required,oneof=PENDING CONFIRMED CHECKIN CHECKOUT CANCELED"`
+	// Units chosen by staff for the room types of the booking at check-in;
+	// the others are assigned automatically
+	RoomIds []uint `json:"roomIds" binding:"max=20,unique,dive,required"`
+}
 
-		// Handle overlapping booking scenario
-		if len(overlappingBookings) > 0 {
-			tx.Rollback()
+type RoomInfo struct {
+	Id       uint   `json:"id"`
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Price    uint   `json:"price"`
+	Adults   uint   `json:"adults"`
+	Children uint   `json:"children"`
+}
 
-			ctx.JSON(http.StatusConflict, gin.H{"error": "Room already booked within this time frame"})
-			return
-		}
+// BookedRoomTypeInfo is a number of rooms of a type reserved by a booking;
+// the units appear in rooms once assigned at check-in.
+type BookedRoomTypeInfo struct {
+	Id       uint   `json:"id"`
+	Name     string `json:"name"`
+	Price    uint   `json:"price"`
+	Quantity uint   `json:"quantity"`
+	Adults   uint   `json:"adults"`
+	Children uint   `json:"children"`
+}
 
-		if room.Status != utils.RoomStatusAvaiable {
-			tx.Rollback()
-			log.Println(">>>CreateBooking 4 ", room.Status)
+type BookingDepositInfo struct {
+	ID      uint    `json:"id"`
+	Image   string  `json:"image"`
+	Deposit float64 `json:"deposit"`
+}
+type PropertyInfo struct {
+	Id             uint    `json:"id"`
+	Name           string  `json:"name"`
+	Address        string  `json:"address"`
+	Fk_Ward_Id     uint    `json:"wardId"`
+	Fk_District_Id uint    `json:"districtId"`
+	Fk_Province_Id uint    `json:"provinceId"`
+	Description    string  `json:"description,omitempty"`
+	Longitude      float64 `json:"longitude,omitempty"`
+	Latitude       float64 `json:"latitude,omitempty"`
+	Status         string  `json:"status"`
+	Type           string  `json:"type"`
+	StayTimes
+	Images []PropertyImage `json:"images"`
+}
+type BookingResponse struct {
+	Id         uint   `json:"id"`
+	Fk_User_Id uint   `json:"userId"`
+	Status     string `json:"status"`
+	// Check-in and check-out instants in UTC, and their dates in the time
+	// zone of the property
+	Start_Date     time.Time            `json:"startDate"`
+	End_Date       time.Time            `json:"endDate"`
+	CheckInDate    Date                 `json:"checkInDate"`
+	CheckOutDate   Date                 `json:"checkOutDate"`
+	Create_At      time.Time            `json:"createAt"`
+	Total_Price    float64              `json:"totalPrice"`
+	Price          PriceBreakdown       `json:"price"`
+	Nights         int                  `json:"nights"`
+	Early_Check_In bool                 `json:"earlyCheckIn"`
+	Late_Check_Out bool                 `json:"lateCheckOut"`
+	Rooms          []RoomInfo           `json:"rooms"`
+	RoomTypes      []BookedRoomTypeInfo `json:"roomTypes"`
+	Deposit        *BookingDepositInfo  `json:"deposit,omitempty"`
+	Property       PropertyInfo         `json:"property"`
+}
 
-			ctx.JSON(http.StatusInternalServerError, errorResponse(fmt.Errorf("room %d not available", roomId)))
-			return
-		}
+// PriceBreakdown shows how the total price of a booking is made up: the
+// subtotal less the discount, plus the charges that are not inclusive.
+type PriceBreakdown struct {
+	Subtotal  float64      `json:"subtotal"`
+	Discount  float64      `json:"discount"`
+	PromoCode string       `json:"promoCode,omitempty"`
+	Charges   []ChargeInfo `json:"charges"`
+	Total     float64      `json:"total"`
+}
 
-		duration := req.EndDate.Sub(req.StartDate).Hours() / 24
-		totalPrice += float64(room.Price) * duration
-	}
+// ChargeInfo is a tax or fee charged on a booking.
+type ChargeInfo struct {
+	Name      string  `json:"name"`
+	Kind      string  `json:"kind"`
+	Basis     string  `json:"basis"`
+	Value     float64 `json:"value"`
+	Inclusive bool    `json:"inclusive"`
+	Amount    float64 `json:"amount"`
+}
 
-	var property db.T_Properties
-	if err := tx.Where("id = ?", req.PropertyId).First(&property).Error; err != nil {
-		tx.Rollback()
-		log.Println(">>>CreateBooking 5 ", err)
+type PropertyImage struct {
+	Id  uint   `json:"id"`
+	Url string `json:"url"`
+}
 
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+func (server *Server) getListBookingByUserId(ctx *gin.Context) {
+	userId, err := strconv.ParseUint(ctx.Param("userId"), 10, 64)
+	if err != nil {
+		respondInvalid(ctx, FieldError{Field: "userId", Rule: "id"})
 		return
 	}
 
-	if property.Status != utils.HotelStatusAvaiable {
-		tx.Rollback()
-		log.Println(">>>CreateBooking 6 hotel not availbe")
-
-		ctx.JSON(http.StatusInternalServerError, errorResponse(fmt.Errorf("hotel not available")))
+	var req listBookingsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		respondInvalid(ctx, err)
 		return
 	}
 
-	var status = utils.BookingStatus_Confirmed
-	if req.Deposit != 0 {
-		status = utils.BookingStatus_Pending
+	location, err := server.listLocation(ctx, req.PropertyId)
+	if err != nil {
+		respondInternalError(ctx, err)
+		return
 	}
-	booking := db.T_Bookings{
-		Fk_User_Id:     req.UserId,
-		Status:         status,
-		Start_Date:     req.StartDate,
-		End_Date:       req.EndDate,
-		Create_At:      time.Now(),
-		Fk_Property_Id: property.Id,
-		Total_Price:    totalPrice,
+	params, err := req.toParams(location)
+	if err != nil {
+		respondInvalid(ctx, err)
+		return
 	}
+	params.UserId = uint(userId)
+	setRequestUser(ctx, params.UserId)
 
-	if err := tx.Create(&booking).Error; err != nil {
-		log.Println(">>>CreateBooking 7", err)
+	server.respondBookingPage(ctx, req, params)
+}
 
-		tx.Rollback()
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+func (server *Server) getListBookingByAgentId(ctx *gin.Context) {
+	agentId, err := strconv.ParseUint(ctx.Param("agentId"), 10, 64)
+	if err != nil {
+		respondInvalid(ctx, FieldError{Field: "agentId", Rule: "id"})
 		return
 	}
-	for _, roomId := range req.RoomIds {
-		bookingRoom := &db.T_Booking_Rooms{
-			Fk_Room_Id:    roomId,
-			Fk_Booking_id: booking.Id,
-		}
-		if err := tx.Create(&bookingRoom).Error; err != nil {
-			log.Println(">>>CreateBooking 8", err)
 
-			tx.Rollback()
-			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
-			return
-		}
+	var req listBookingsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		respondInvalid(ctx, err)
+		return
 	}
 
-	// Save uploaded images
-	form, err := ctx.MultipartForm()
+	location, err := server.listLocation(ctx, req.PropertyId)
 	if err != nil {
-		tx.Rollback()
-		log.Println(">>>CreateBooking form", err)
-
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		respondInternalError(ctx, err)
 		return
 	}
-	files := form.File["image"]
-	log.Println(">>>CreateBooking files", files)
-
-	if len(files) != 0 {
-
-		for _, file := range files {
-			filePath := fmt.Sprintf("uploads/%s", file.Filename) // Customize this path as needed
-			deposit := db.T_Booking_Deposits{
-				Fk_Booking_ID: booking.Id,
-				Deposit:       req.Deposit,
-			}
-
-			// Save the file locally
-			if err := ctx.SaveUploadedFile(file, filePath); err != nil {
-				log.Println(">>>CreateBooking save file", err)
-
-				tx.Rollback()
-				ctx.JSON(http.StatusInternalServerError, errorResponse(err))
-				return
-			}
-
-			fullPath := fmt.Sprintf("%s/%s", utils.URL_API, filePath)
-			imagePath := &fullPath
-
-			deposit.Image = imagePath
-			if err := tx.Create(&deposit).Error; err != nil {
-
-				tx.Rollback()
-				ctx.JSON(http.StatusInternalServerError, errorResponse(err))
-				return
-			}
-		}
+	params, err := req.toParams(location)
+	if err != nil {
+		respondInvalid(ctx, err)
+		return
 	}
-	log.Println(">>>CreateBooking done")
+	params.AgentId = uint(agentId)
+	annotateSpan(ctx, attribute.Int64("agent.id", int64(agentId)))
 
-	// Create booking deposit record if deposit is provided
+	// Make sure the agent owns at least one property
+	propertyCount, err := server.store.CountPropertiesByAgent(ctx, uint(agentId))
+	if err != nil {
+		respondInternalError(ctx, err)
+		return
+	}
 
-	// Commit the transaction
-	tx.Commit()
+	if propertyCount == 0 {
+		respondError(ctx, http.StatusNotFound, CodeNotFound, "No properties found for the given agent")
+		return
+	}
 
-	ctx.JSON(http.StatusOK, booking)
+	server.respondBookingPage(ctx, req, params)
 }
 
-type RoomInfo struct {
-	Id     uint   `json:"id"`
-	Name   string `json:"name"`
-	Status string `json:"status"`
-	Price  uint   `json:"price"`
+// listLocation is the time zone the date filters of a booking list are
+// read in: that of the filtered property, or PROPERTY_TIMEZONE when the
+// list spans properties or the property does not exist.
+func (server *Server) listLocation(ctx *gin.Context, propertyId uint) (*time.Location, error) {
+	if propertyId == 0 {
+		return server.stays.location, nil
+	}
+	property, err := server.store.GetProperty(ctx, propertyId)
+	if errors.Is(err, db.ErrNotFound) {
+		return server.stays.location, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	policy, err := server.stays.policy(property)
+	if err != nil {
+		return nil, err
+	}
+	return policy.location, nil
 }
 
-type BookingDepositInfo struct {
-	ID      uint    `json:"id"`
-	Image   string  `json:"image"`
-	Deposit float64 `json:"deposit"`
-}
-type PropertyInfo struct {
-	Id             uint            `json:"id"`
-	Name           string          `json:"name"`
-	Address        string          `json:"address"`
-	Fk_Ward_Id     uint            `json:"wardId"`
-	Fk_District_Id uint            `json:"districtId"`
-	Fk_Province_Id uint            `json:"provinceId"`
-	Description    string          `json:"description,omitempty"`
-	Longitude      float64         `json:"longitude,omitempty"`
-	Latitude       float64         `json:"latitude,omitempty"`
-	Status         string          `json:"status"`
-	Type           string          `json:"type"`
-	Images         []PropertyImage `json:"images"`
-}
-type BookingResponse struct {
-	Id          uint                `json:"id"`
-	Fk_User_Id  uint                `json:"userId"`
-	Status      string              `json:"status"`
-	Start_Date  time.Time           `json:"startDate"`
-	End_Date    time.Time           `json:"endDate"`
-	Create_At   time.Time           `json:"createAt"`
-	Total_Price float64             `json:"totalPrice"`
-	Rooms       []RoomInfo          `json:"rooms"`
-	Deposit     *BookingDepositInfo `json:"deposit,omitempty"`
-	Property    PropertyInfo        `json:"property"`
-}
-type PropertyImage struct {
-	Id  uint   `json:"id"`
-	Url string `json:"url"`
-}
+// respondBookingPage lists the bookings matching params and writes them as
+// one page, fetching a single extra row to find out whether more remain.
+func (server *Server) respondBookingPage(ctx *gin.Context, req listBookingsRequest, params db.BookingListParams) {
+	params.Limit = req.Limit + 1
+	bookings, err := server.store.ListBookings(ctx, params)
+	if err != nil {
+		respondInternalError(ctx, err)
+		return
+	}
 
-func (server *Server) getListBookingByUserId(ctx *gin.Context) {
-	userId := ctx.Param("userId")
+	paging := Paging{Limit: req.Limit}
+	if len(bookings) > req.Limit {
+		bookings = bookings[:req.Limit]
+		paging.HasMore = true
+		paging.NextCursor = nextBookingCursor(req, bookings[len(bookings)-1])
+	}
 
-	var bookings []db.T_Bookings
-	if err := server.store.Where("fk_user_id = ?", userId).Find(&bookings).Error; err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+	bookingResponses, err := server.buildBookingResponses(ctx, bookings)
+	if err != nil {
+		respondInternalError(ctx, err)
 		return
 	}
 
-	var bookingResponses = []BookingResponse{}
+	respondPage(ctx, bookingResponses, paging)
+}
+
+// buildBookingResponses loads rooms, deposits, properties and property images
+// for all bookings at once, so the number of queries does not grow with the
+// number of bookings.
+func (server *Server) buildBookingResponses(ctx *gin.Context, bookings []db.T_Bookings) ([]BookingResponse, error) {
+	bookingResponses := []BookingResponse{}
+	if len(bookings) == 0 {
+		return bookingResponses, nil
+	}
 
+	bookingIds := make([]uint, 0, len(bookings))
+	propertyIds := make([]uint, 0, len(bookings))
+	seenProperties := map[uint]bool{}
 	for _, booking := range bookings {
-		var rooms []db.T_Rooms
-		if err := server.store.Joins("JOIN t_booking_rooms ON t_booking_rooms.fk_room_id = t_rooms.id").
-			Where("t_booking_rooms.fk_booking_id = ?", booking.Id).Find(&rooms).Error; err != nil {
-			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
-			return
+		bookingIds = append(bookingIds, booking.Id)
+		if !seenProperties[booking.Fk_Property_Id] {
+			seenProperties[booking.Fk_Property_Id] = true
+			propertyIds = append(propertyIds, booking.Fk_Property_Id)
 		}
+	}
 
-		var roomInfos []RoomInfo
-		for _, room := range rooms {
-			roomInfos = append(roomInfos, RoomInfo{
-				Id:     room.Id,
-				Name:   room.Name,
-				Status: room.Status,
-				Price:  room.Price,
-			})
-		}
+	// Rooms of every booking
+	bookedRooms, err := server.store.ListBookedRooms(ctx, bookingIds)
+	if err != nil {
+		return nil, err
+	}
+	roomsByBooking := map[uint][]RoomInfo{}
+	for _, room := range bookedRooms {
+		roomsByBooking[room.Fk_Booking_Id] = append(roomsByBooking[room.Fk_Booking_Id], RoomInfo{
+			Id:       room.Id,
+			Name:     room.Name,
+			Status:   room.Status,
+			Price:    room.Price,
+			Adults:   room.Adults,
+			Children: room.Children,
+		})
+	}
 
-		var deposit db.T_Booking_Deposits
-		var depositResponse *BookingDepositInfo = nil
-		if err := server.store.Where("fk_booking_id = ?", booking.Id).First(&deposit).Error; err == nil {
-			depositResponse = &BookingDepositInfo{
-				ID:      deposit.ID,
-				Image:   *deposit.Image,
-				Deposit: deposit.Deposit,
-			}
-		}
+	bookedRoomTypes, err := server.store.ListBookedRoomTypes(ctx, bookingIds)
+	if err != nil {
+		return nil, err
+	}
+	roomTypesByBooking := map[uint][]BookedRoomTypeInfo{}
+	for _, roomType := range bookedRoomTypes {
+		roomTypesByBooking[roomType.Fk_Booking_Id] = append(roomTypesByBooking[roomType.Fk_Booking_Id], BookedRoomTypeInfo{
+			Id:       roomType.Id,
+			Name:     roomType.Name,
+			Price:    roomType.Price,
+			Quantity: roomType.Quantity,
+			Adults:   roomType.Adults,
+			Children: roomType.Children,
+		})
+	}
 
-		var property db.T_Properties
-		if err := server.store.Where("id = ?", rooms[0].Fk_Property_Id).First(&property).Error; err != nil {
-			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
-			return
+	// Deposits, keeping the first one recorded for each booking
+	deposits, err := server.store.ListBookingDeposits(ctx, bookingIds)
+	if err != nil {
+		return nil, err
+	}
+	depositByBooking := map[uint]*BookingDepositInfo{}
+	for _, deposit := range deposits {
+		if _, ok := depositByBooking[deposit.Fk_Booking_ID]; ok {
+			continue
 		}
-		var propertyImages []db.T_Property_Images
-		if err := server.store.Where("fk_property_id = ?", property.Id).Find(&propertyImages).Error; err != nil {
-			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
-			return
+		depositInfo := &BookingDepositInfo{
+			ID:      deposit.ID,
+			Deposit: deposit.Deposit,
 		}
+		if deposit.Image != nil {
+			depositInfo.Image = *deposit.Image
+		}
+		depositByBooking[deposit.Fk_Booking_ID] = depositInfo
+	}
 
-		var images []PropertyImage
-		for _, img := range propertyImages {
-			images = append(images, PropertyImage{
-				Id:  img.Id,
-				Url: img.Url,
-			})
+	// Taxes and fees of every booking
+	lineItems, err := server.store.ListBookingLineItems(ctx, bookingIds)
+	if err != nil {
+		return nil, err
+	}
+	chargesByBooking := map[uint][]ChargeInfo{}
+	for _, item := range lineItems {
+		chargesByBooking[item.Fk_Booking_Id] = append(chargesByBooking[item.Fk_Booking_Id], ChargeInfo{
+			Name:      item.Name,
+			Kind:      item.Kind,
+			Basis:     item.Basis,
+			Value:     item.Value,
+			Inclusive: item.Inclusive,
+			Amount:    item.Amount,
+		})
+	}
+
+	// Codes of the promotions the bookings redeemed
+	var promotionIds []uint
+	for _, booking := range bookings {
+		if booking.Fk_Promotion_Id != nil && !slices.Contains(promotionIds, *booking.Fk_Promotion_Id) {
+			promotionIds = append(promotionIds, *booking.Fk_Promotion_Id)
 		}
-		propertyInfo := PropertyInfo{
+	}
+	promotions, err := server.store.ListPromotionsByIds(ctx, promotionIds)
+	if err != nil {
+		return nil, err
+	}
+	promoCodes := map[uint]string{}
+	for _, promotion := range promotions {
+		promoCodes[promotion.Id] = promotion.Code
+	}
+
+	// Properties and their images
+	properties, err := server.store.ListPropertiesByIds(ctx, propertyIds)
+	if err != nil {
+		return nil, err
+	}
+	propertyImages, err := server.store.ListPropertyImages(ctx, propertyIds)
+	if err != nil {
+		return nil, err
+	}
+	imagesByProperty := map[uint][]PropertyImage{}
+	for _, img := range propertyImages {
+		imagesByProperty[img.Fk_Property_Id] = append(imagesByProperty[img.Fk_Property_Id], PropertyImage{
+			Id:  img.Id,
+			Url: img.Url,
+		})
+	}
+	propertyById := map[uint]PropertyInfo{}
+	policyByProperty := map[uint]stayPolicy{}
+	for _, property := range properties {
+		policy, err := server.stays.policy(property)
+		if err != nil {
+			return nil, err
+		}
+		policyByProperty[property.Id] = policy
+		propertyById[property.Id] = PropertyInfo{
 			Id:             property.Id,
 			Name:           property.Name,
 			Address:        property.Address,
@@ -434,210 +658,623 @@ func (server *Server) getListBookingByUserId(ctx *gin.Context) {
 			Latitude:       property.Latitude.Float64,
 			Status:         property.Status,
 			Type:           property.Type,
-			Images:         images,
+			StayTimes:      policy.times(),
+			Images:         imagesByProperty[property.Id],
 		}
+	}
 
-		bookingResponse := BookingResponse{
-			Id:          booking.Id,
-			Fk_User_Id:  booking.Fk_User_Id,
-			Status:      booking.Status,
-			Start_Date:  booking.Start_Date,
-			End_Date:    booking.End_Date,
-			Create_At:   booking.Create_At,
-			Total_Price: booking.Total_Price,
-			Rooms:       roomInfos,
-			Deposit:     depositResponse,
-			Property:    propertyInfo,
+	for _, booking := range bookings {
+		policy, ok := policyByProperty[booking.Fk_Property_Id]
+		if !ok {
+			policy = server.stays.defaultPolicy()
 		}
-
-		bookingResponses = append(bookingResponses, bookingResponse)
+		price := PriceBreakdown{
+			Subtotal: booking.Subtotal_Price,
+			Discount: booking.Discount,
+			Charges:  chargesByBooking[booking.Id],
+			Total:    booking.Total_Price,
+		}
+		if price.Charges == nil {
+			price.Charges = []ChargeInfo{}
+		}
+		if booking.Fk_Promotion_Id != nil {
+			price.PromoCode = promoCodes[*booking.Fk_Promotion_Id]
+		}
+		bookingResponses = append(bookingResponses, BookingResponse{
+			Id:             booking.Id,
+			Fk_User_Id:     booking.Fk_User_Id,
+			Status:         booking.Status,
+			Start_Date:     booking.Start_Date.UTC(),
+			End_Date:       booking.End_Date.UTC(),
+			CheckInDate:    policy.localDate(booking.Start_Date),
+			CheckOutDate:   policy.localDate(booking.End_Date),
+			Create_At:      booking.Create_At.UTC(),
+			Total_Price:    booking.Total_Price,
+			Price:          price,
+			Nights:         booking.Nights,
+			Early_Check_In: booking.Early_Check_In,
+			Late_Check_Out: booking.Late_Check_Out,
+			Rooms:          roomsByBooking[booking.Id],
+			RoomTypes:      roomTypesByBooking[booking.Id],
+			Deposit:        depositByBooking[booking.Id],
+			Property:       propertyById[booking.Fk_Property_Id],
+		})
 	}
 
-	ctx.JSON(http.StatusOK, bookingResponses)
+	return bookingResponses, nil
 }
 
-func (server *Server) getListBookingByAgentId(ctx *gin.Context) {
-	agentId := ctx.Param("agentId")
+type GuestInfo struct {
+	Id          uint   `json:"id"`
+	FirstName   string `json:"firstName"`
+	LastName    string `json:"lastName"`
+	Email       string `json:"email,omitempty"`
+	PhoneNumber string `json:"phoneNumber"`
+	Avatar      string `json:"avatar,omitempty"`
+}
+
+type BookingStatusChange struct {
+	FromStatus string    `json:"fromStatus,omitempty"`
+	ToStatus   string    `json:"toStatus"`
+	ChangedAt  time.Time `json:"changedAt"`
+	// Note describes what changed when the booking was modified without a
+	// status change
+	Note string `json:"note,omitempty"`
+}
 
-	// 1. Retrieve properties by AgentId
-	var properties []db.T_Properties
-	if err := server.store.Where("fk_argent_id = ?", agentId).Find(&properties).Error; err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+type PaymentSummary struct {
+	TotalPrice      float64 `json:"totalPrice"`
+	DepositPercent  float64 `json:"depositPercent"`
+	RequiredDeposit float64 `json:"requiredDeposit"`
+	DepositPaid     float64 `json:"depositPaid"`
+	BalanceDue      float64 `json:"balanceDue"`
+}
+
+type BookingDetailResponse struct {
+	BookingResponse
+	Guest         *GuestInfo            `json:"guest"`
+	StatusHistory []BookingStatusChange `json:"statusHistory"`
+	Payment       PaymentSummary        `json:"payment"`
+}
+
+func (server *Server) getById(ctx *gin.Context) {
+	bookingId, err := strconv.ParseUint(ctx.Param("bookingId"), 10, 64)
+	if err != nil {
+		respondInvalid(ctx, FieldError{Field: "bookingId", Rule: "id"})
 		return
 	}
+	annotateSpan(ctx, attribute.Int64("booking.id", int64(bookingId)))
 
-	if len(properties) == 0 {
-		ctx.JSON(http.StatusNotFound, gin.H{"error": "No properties found for the given agent"})
+	booking, err := server.store.GetBooking(ctx, uint(bookingId))
+	if errors.Is(err, db.ErrNotFound) {
+		err = errBookingNotFound
+	}
+	if err != nil {
+		respondErr(ctx, err)
 		return
 	}
 
-	var bookingResponses []BookingResponse
+	bookingResponses, err := server.buildBookingResponses(ctx, []db.T_Bookings{booking})
+	if err != nil {
+		respondInternalError(ctx, err)
+		return
+	}
+	detail := BookingDetailResponse{
+		BookingResponse: bookingResponses[0],
+		StatusHistory:   []BookingStatusChange{},
+	}
 
-	for _, property := range properties {
-		// 2. Get bookings associated with this property
-		var bookings []db.T_Bookings
-		if err := server.store.Where("fk_property_id = ?", property.Id).Find(&bookings).Error; err != nil {
-			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
-			return
+	guest, err := server.store.GetUser(ctx, booking.Fk_User_Id)
+	if err != nil && !errors.Is(err, db.ErrNotFound) {
+		respondInternalError(ctx, err)
+		return
+	}
+	if err == nil {
+		detail.Guest = &GuestInfo{
+			Id:          guest.Id,
+			FirstName:   guest.First_Name,
+			LastName:    guest.Last_Name,
+			PhoneNumber: guest.Phone_Number,
+			Avatar:      guest.Avatar,
+		}
+		if guest.Email != nil {
+			detail.Guest.Email = *guest.Email
 		}
+	}
 
-		for _, booking := range bookings {
-			// 3. Retrieve corresponding T_Booking_Deposits (if it exists)
-			var deposit db.T_Booking_Deposits
-			depositExists := server.store.Where("fk_booking_id = ?", booking.Id).First(&deposit).RowsAffected > 0
+	histories, err := server.store.ListBookingStatusHistory(ctx, booking.Id)
+	if err != nil {
+		respondInternalError(ctx, err)
+		return
+	}
+	for _, history := range histories {
+		detail.StatusHistory = append(detail.StatusHistory, BookingStatusChange{
+			FromStatus: history.From_Status,
+			ToStatus:   history.To_Status,
+			ChangedAt:  history.Create_At.UTC(),
+			Note:       history.Note,
+		})
+	}
 
-			// 4. Retrieve corresponding T_Booking_Rooms
-			var bookingRooms []db.T_Booking_Rooms
-			if err := server.store.Where("fk_booking_id = ?", booking.Id).Find(&bookingRooms).Error; err != nil {
-				ctx.JSON(http.StatusInternalServerError, errorResponse(err))
-				return
-			}
+	// Payment summary over every deposit recorded for the booking
+	deposits, err := server.store.ListBookingDeposits(ctx, []uint{booking.Id})
+	if err != nil {
+		respondInternalError(ctx, err)
+		return
+	}
+	var depositPaid float64
+	for _, deposit := range deposits {
+		depositPaid += deposit.Deposit
+	}
+	property, err := server.store.GetProperty(ctx, booking.Fk_Property_Id)
+	if err != nil && !errors.Is(err, db.ErrNotFound) {
+		respondInternalError(ctx, err)
+		return
+	}
+	detail.Payment = PaymentSummary{
+		TotalPrice:      booking.Total_Price,
+		DepositPercent:  property.Deposit_Percent,
+		RequiredDeposit: booking.Total_Price * property.Deposit_Percent / 100,
+		DepositPaid:     depositPaid,
+		BalanceDue:      booking.Total_Price - depositPaid,
+	}
 
-			// 5. Loop through T_Booking_Rooms to get room details
-			var roomInfos []RoomInfo
-			for _, bookingRoom := range bookingRooms {
-				var room db.T_Rooms
-				if err := server.store.Where("id = ?", bookingRoom.Fk_Room_Id).First(&room).Error; err != nil {
-					ctx.JSON(http.StatusInternalServerError, errorResponse(err))
-					return
-				}
-				roomInfos = append(roomInfos, RoomInfo{
-					Id:     room.Id,
-					Name:   room.Name,
-					Status: room.Status,
-					Price:  room.Price,
-				})
+	respondOK(ctx, detail)
+}
+func (server *Server) updateBookingStatus(ctx *gin.Context) {
+	var req updateStatusRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondInvalid(ctx, err)
+		return
+	}
+	annotateSpan(ctx,
+		attribute.Int64("booking.id", int64(req.BookingId)),
+		attribute.String("booking.status", req.Status),
+	)
+
+	var booking db.T_Bookings
+	err := server.store.ExecTx(ctx, func(store db.Store) error {
+		var err error
+		booking, err = store.GetBooking(ctx, req.BookingId)
+		if errors.Is(err, db.ErrNotFound) {
+			return errBookingNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		previousStatus := booking.Status
+
+		// Checking in an already checked-in booking checks it out
+		switch {
+		case req.Status == utils.BookingStatus_CheckIn && booking.Status == utils.BookingStatus_CheckIn:
+			booking.Status = utils.BookingStatus_CheckOut
+		case req.Status == utils.BookingStatus_CheckIn:
+			property, err := store.GetProperty(ctx, booking.Fk_Property_Id)
+			if err != nil {
+				return err
+			}
+			policy, err := server.stays.policy(property)
+			if err != nil {
+				return err
+			}
+			if err := policy.checkCheckIn(booking, time.Now()); err != nil {
+				return err
 			}
+			if err := assignUnits(ctx, store, booking, req.RoomIds); err != nil {
+				return err
+			}
+			booking.Status = req.Status
+		default:
+			booking.Status = req.Status
+		}
 
-			propertyInfo := PropertyInfo{
-				Id:             property.Id,
-				Name:           property.Name,
-				Address:        property.Address,
-				Fk_Ward_Id:     property.Fk_Ward_Id,
-				Fk_District_Id: property.Fk_District_Id,
-				Fk_Province_Id: property.Fk_Province_Id,
-				Description:    property.Description.String,
-				Longitude:      property.Longitude.Float64,
-				Latitude:       property.Latitude.Float64,
-				Status:         property.Status,
-				Type:           property.Type,
-			}
-
-			bookingResponse := BookingResponse{
-				Id:          booking.Id,
-				Fk_User_Id:  booking.Fk_User_Id,
-				Status:      booking.Status,
-				Start_Date:  booking.Start_Date,
-				End_Date:    booking.End_Date,
-				Create_At:   booking.Create_At,
-				Total_Price: booking.Total_Price,
-				Rooms:       roomInfos,
-				Property:    propertyInfo,
-			}
-
-			if depositExists {
-				bookingResponse.Deposit = &BookingDepositInfo{
-					ID:      deposit.ID,
-					Image:   *deposit.Image,
-					Deposit: deposit.Deposit,
+		// A canceled booking gives its promotion redemption back, and takes
+		// it again if reopened
+		if booking.Fk_Promotion_Id != nil {
+			canceled := booking.Status == utils.BookingStatus_Canceled
+			if canceled != (previousStatus == utils.BookingStatus_Canceled) {
+				if err := changeRedemptions(ctx, store, *booking.Fk_Promotion_Id, canceled); err != nil {
+					return err
 				}
 			}
+		}
 
-			bookingResponses = append(bookingResponses, bookingResponse)
+		if err := store.UpdateBooking(ctx, &booking); err != nil {
+			return err
 		}
+
+		if booking.Status == previousStatus {
+			return nil
+		}
+		return store.AddBookingStatusHistory(ctx, &db.T_Booking_Status_Histories{
+			Fk_Booking_Id: booking.Id,
+			From_Status:   previousStatus,
+			To_Status:     booking.Status,
+			Create_At:     time.Now().UTC(),
+		})
+	})
+	if err != nil {
+		respondErr(ctx, err)
+		return
 	}
 
-	ctx.JSON(http.StatusOK, bookingResponses)
+	respondOK(ctx, inUTC(booking))
 }
 
-func (server *Server) getById(ctx *gin.Context) {
-	bookingId := ctx.Param("bookingId")
+type modifyBookingRequest struct {
+	// New arrival and departure dates in the local time of the hotel; a
+	// missing date keeps the current one
+	StartDate     Date   `json:"startDate" binding:"omitempty,date"`
+	EndDate       Date   `json:"endDate" binding:"omitempty,date"`
+	AddRoomIds    []uint `json:"addRoomIds" binding:"max=20,unique,dive,required"`
+	RemoveRoomIds []uint `json:"removeRoomIds" binding:"unique,dive,required"`
+	// Guests of the added rooms, one adult for those left out, and new
+	// guests of rooms already booked
+	Guests       []roomGuests `json:"guests" binding:"max=40,unique=RoomId,dive"`
+	EarlyCheckIn *bool        `json:"earlyCheckIn"`
+	LateCheckOut *bool        `json:"lateCheckOut"`
+}
 
-	var booking db.T_Bookings
-	if err := server.store.Where("id = ?", bookingId).First(&booking).Error; err != nil {
-		ctx.JSON(http.StatusNotFound, errorResponse(err))
+// modifyBooking changes the dates, rooms, guests or check-in options of a booking
+// that has not started yet. The new stay must be available for every room
+// of the booking, ignoring the booking itself. Rooms and nights that remain
+// booked keep their booked price, added ones are priced at the current
+// rates, and deposits already paid are kept.
+func (server *Server) modifyBooking(ctx *gin.Context) {
+	bookingId, err := strconv.ParseUint(ctx.Param("bookingId"), 10, 64)
+	if err != nil {
+		respondInvalid(ctx, FieldError{Field: "bookingId", Rule: "id"})
 		return
 	}
-
-	ctx.JSON(http.StatusOK, booking)
-}
-func (server *Server) updateBookingStatus(ctx *gin.Context) {
-	var req updateStatusRequest
+	var req modifyBookingRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		respondInvalid(ctx, err)
 		return
 	}
-	log.Println(">>> req", req)
-	validStatuses := map[string]bool{
-		utils.BookingStatus_CheckOut:  true,
-		utils.BookingStatus_Confirmed: true,
-		utils.BookingStatus_Pending:   true,
-		utils.BookingStatus_Canceled:  true,
-		utils.BookingStatus_CheckIn:   true,
-	}
+	annotateSpan(ctx, attribute.Int64("booking.id", int64(bookingId)))
 
-	if !validStatuses[req.Status] {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status value"})
-		return
-	}
-	log.Println(">>> req validated status")
+	var booking db.T_Bookings
+	err = server.store.ExecTx(ctx, func(store db.Store) error {
+		var err error
+		booking, err = store.GetBooking(ctx, uint(bookingId))
+		if errors.Is(err, db.ErrNotFound) {
+			return errBookingNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if booking.Status != utils.BookingStatus_Pending && booking.Status != utils.BookingStatus_Confirmed {
+			return errBookingNotModifiable
+		}
 
-	// Start a transaction
-	tx := server.store.Begin()
+		property, err := store.GetProperty(ctx, booking.Fk_Property_Id)
+		if err != nil {
+			return err
+		}
+		policy, err := server.stays.policy(property)
+		if err != nil {
+			return err
+		}
 
-	var booking db.T_Bookings
-	if err := tx.Where("id = ?", req.BookingId).First(&booking).Error; err != nil {
-		log.Println(">>> req get booking err", err)
+		bookedArrival, bookedDeparture := policy.localDate(booking.Start_Date), policy.localDate(booking.End_Date)
+		arrival, departure := bookedArrival, bookedDeparture
+		if !req.StartDate.IsZero() {
+			if req.StartDate.Before(policy.today(time.Now()).Time) {
+				return FieldError{Field: "startDate", Rule: "notpast"}
+			}
+			arrival = req.StartDate
+		}
+		if !req.EndDate.IsZero() {
+			departure = req.EndDate
+		}
+		switch nights := stayNights(arrival, departure); {
+		case nights < 1:
+			return FieldError{Field: "endDate", Rule: "minstay", param: "1"}
+		case nights > maxStayNights:
+			return FieldError{Field: "endDate", Rule: "maxstay", param: strconv.Itoa(maxStayNights)}
+		}
 
-		tx.Rollback()
-		ctx.JSON(http.StatusNotFound, errorResponse(err))
-		return
-	}
+		earlyCheckIn, lateCheckOut := booking.Early_Check_In, booking.Late_Check_Out
+		if req.EarlyCheckIn != nil {
+			earlyCheckIn = *req.EarlyCheckIn
+		}
+		if req.LateCheckOut != nil {
+			lateCheckOut = *req.LateCheckOut
+		}
+		stay, err := policy.stay(arrival, departure, earlyCheckIn, lateCheckOut)
+		if err != nil {
+			return err
+		}
+		// Stay restrictions apply to new dates, and to rooms joining the
+		// booking; rooms kept on the same dates were accepted when booked
+		datesChanged := !arrival.Equal(bookedArrival.Time) || !departure.Equal(bookedDeparture.Time)
+		// Nights that remain booked keep the price they were booked at
+		keptNights := sharedNights(arrival, departure, bookedArrival, bookedDeparture)
+		restrictions, err := store.ListStayRestrictions(ctx, property.Id)
+		if err != nil {
+			return err
+		}
+		if datesChanged {
+			if err := rulesFor(restrictions, 0, 0).check(arrival, departure); err != nil {
+				return err
+			}
+		}
 
-	// Check if the status is CheckIn and if it is within the allowed check-in time window
+		bookedRooms, err := store.ListBookedRooms(ctx, []uint{booking.Id})
+		if err != nil {
+			return err
+		}
+		var roomIds []uint
+		guestsByRoom := map[uint]roomGuests{}
+		bookedAmounts := map[uint]float64{}
+		for _, room := range bookedRooms {
+			roomIds = append(roomIds, room.Id)
+			guestsByRoom[room.Id] = roomGuests{RoomId: room.Id, Adults: room.Adults, Children: room.Children}
+			bookedAmounts[room.Id] = room.Amount
+		}
+		for _, roomId := range req.RemoveRoomIds {
+			if !slices.Contains(roomIds, roomId) {
+				return errRoomNotInBooking
+			}
+		}
+		// A room both removed and added stays booked
+		roomIds = slices.DeleteFunc(roomIds, func(roomId uint) bool {
+			return slices.Contains(req.RemoveRoomIds, roomId) && !slices.Contains(req.AddRoomIds, roomId)
+		})
+		var addedRoomIds []uint
+		for _, roomId := range req.AddRoomIds {
+			if !slices.Contains(roomIds, roomId) {
+				addedRoomIds = append(addedRoomIds, roomId)
+			}
+		}
+		roomIds = append(roomIds, addedRoomIds...)
+		removedRoomIds := slices.DeleteFunc(slices.Clone(req.RemoveRoomIds), func(roomId uint) bool {
+			return slices.Contains(roomIds, roomId)
+		})
+		bookedTypes, err := store.ListBookedRoomTypes(ctx, []uint{booking.Id})
+		if err != nil {
+			return err
+		}
+		if len(roomIds) == 0 && len(bookedTypes) == 0 {
+			return FieldError{Field: "removeRoomIds", Rule: "lastroom"}
+		}
 
-	if req.Status == utils.BookingStatus_CheckIn && booking.Status == utils.BookingStatus_CheckIn {
+		var regrouped []roomGuests
+		for _, guests := range req.Guests {
+			if !slices.Contains(roomIds, guests.RoomId) {
+				return errRoomNotInBooking
+			}
+			if current, ok := guestsByRoom[guests.RoomId]; ok && current != guests && !slices.Contains(addedRoomIds, guests.RoomId) {
+				regrouped = append(regrouped, guests)
+			}
+			guestsByRoom[guests.RoomId] = guests
+		}
+		for _, roomId := range addedRoomIds {
+			if _, ok := guestsByRoom[roomId]; !ok {
+				guestsByRoom[roomId] = defaultGuests(roomId)
+			}
+		}
 
-		booking.Status = utils.BookingStatus_CheckOut
-		// now := time.Now()
-		// startTime := time.Date(booking.Start_Date.Year(), booking.Start_Date.Month(), booking.Start_Date.Day(), 12, 0, 0, 0, booking.Start_Date.Location())
-		// endTime := startTime.Add(15 * time.Hour) // From 12 PM to 3 AM next day
+		// Every room is checked again, as the stay may have moved
+		var lines []priceLine
+		for _, roomId := range roomIds {
+			room, err := store.GetRoom(ctx, roomId)
+			if errors.Is(err, db.ErrNotFound) {
+				return errRoomNotFound
+			}
+			if err != nil {
+				return err
+			}
+			if room.Fk_Property_Id != property.Id {
+				return errRoomNotInHotel
+			}
+			if room.Fk_Room_Type_Id != nil && slices.Contains(addedRoomIds, roomId) {
+				return errRoomBookedByType
+			}
+			if datesChanged || slices.Contains(addedRoomIds, roomId) {
+				if err := rulesFor(restrictions, room.Id, 0).check(arrival, departure); err != nil {
+					return err
+				}
+			}
+			overlapping, err := store.HasOverlappingBooking(ctx, room.Id, stay.start, stay.end, booking.Id)
+			if err != nil {
+				return err
+			}
+			if overlapping {
+				return errRoomAlreadyBooked
+			}
+			// Rooms already in the booking stay reserved even if they are no
+			// longer offered to new bookings
+			if slices.Contains(addedRoomIds, roomId) && room.Status != utils.RoomStatusAvaiable {
+				return errRoomNotAvailable
+			}
+			if !guestsByRoom[roomId].fits(room.Max_Adults, room.Max_Children) {
+				return errRoomOverOccupied
+			}
+			amount := float64(room.Price) * float64(stay.nights)
+			if booked, ok := bookedAmounts[roomId]; ok {
+				amount = repriced(booked, booking.Nights, keptNights, stay.nights, float64(room.Price))
+			}
+			lines = append(lines, priceLine{roomId: room.Id, amount: amount})
+		}
+		for _, bookedType := range bookedTypes {
+			roomType, err := store.LockRoomType(ctx, bookedType.Id)
+			if err != nil {
+				return err
+			}
+			if datesChanged {
+				if err := rulesFor(restrictions, 0, roomType.Id).check(arrival, departure); err != nil {
+					return err
+				}
+			}
+			if err := checkRoomTypeAvailable(ctx, store, roomType, bookedType.Quantity, stay, booking.Id); err != nil {
+				return err
+			}
+			lines = append(lines, priceLine{
+				roomTypeId: roomType.Id,
+				amount: repriced(bookedType.Amount, booking.Nights, keptNights, stay.nights,
+					float64(roomType.Price)*float64(bookedType.Quantity)),
+			})
+		}
 
-		// if !(now.After(startTime) && now.Before(endTime)) {
-		// 	tx.Rollback()
-		// 	ctx.JSON(http.StatusBadRequest, gin.H{"error": "Check-in is only allowed between 12 PM and 3 AM the next day"})
-		// 	return
-		// }
-	} else {
-		booking.Status = req.Status
-	}
+		// The price only changes with the nights or rooms booked
+		subtotalPrice, discount, totalPrice := booking.Subtotal_Price, booking.Discount, booking.Total_Price
+		var lineItems []db.T_Booking_Line_Items
+		priceChanged := datesChanged || len(addedRoomIds) > 0 || len(removedRoomIds) > 0
+		if priceChanged {
+			// The promotion the booking redeemed must still apply to the
+			// changed stay; it is not redeemed again
+			discount = 0
+			if booking.Fk_Promotion_Id != nil {
+				promotion, err := store.GetPromotion(ctx, *booking.Fk_Promotion_Id)
+				if err != nil {
+					return err
+				}
+				rules, err := loadPromotion(ctx, store, promotion)
+				if err != nil {
+					return err
+				}
+				discount, err = rules.discount(property, arrival, stay.nights, lines)
+				if err != nil {
+					return err
+				}
+			}
+			// Taxes and fees keep the rates they were booked at
+			bookedItems, err := store.ListBookingLineItems(ctx, []uint{booking.Id})
+			if err != nil {
+				return err
+			}
+			rooms := len(roomIds)
+			for _, bookedType := range bookedTypes {
+				rooms += int(bookedType.Quantity)
+			}
+			var exclusive float64
+			subtotalPrice = subtotal(lines)
+			lineItems, exclusive = repriceLineItems(bookedItems, subtotalPrice-discount, rooms, stay.nights)
+			totalPrice = subtotalPrice - discount + exclusive
+		}
 
-	if err := tx.Save(&booking).Error; err != nil {
-		log.Println(">>> req save booking err", err)
+		var changes []string
+		if datesChanged {
+			changes = append(changes, fmt.Sprintf("stay %s to %s changed to %s to %s", bookedArrival, bookedDeparture, arrival, departure))
+		}
+		if earlyCheckIn != booking.Early_Check_In {
+			changes = append(changes, fmt.Sprintf("early check-in set to %t", earlyCheckIn))
+		}
+		if lateCheckOut != booking.Late_Check_Out {
+			changes = append(changes, fmt.Sprintf("late check-out set to %t", lateCheckOut))
+		}
+		if len(addedRoomIds) > 0 {
+			changes = append(changes, fmt.Sprintf("rooms %v added", addedRoomIds))
+		}
+		if len(removedRoomIds) > 0 {
+			changes = append(changes, fmt.Sprintf("rooms %v removed", removedRoomIds))
+		}
+		for _, guests := range regrouped {
+			changes = append(changes, fmt.Sprintf("guests of room %d set to %d adults and %d children",
+				guests.RoomId, guests.Adults, guests.Children))
+		}
+		if totalPrice != booking.Total_Price {
+			changes = append(changes, fmt.Sprintf("total price %.2f changed to %.2f", booking.Total_Price, totalPrice))
+		}
+		if len(changes) == 0 {
+			return nil
+		}
 
-		tx.Rollback()
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		booking.Start_Date = stay.start
+		booking.End_Date = stay.end
+		booking.Nights = stay.nights
+		booking.Early_Check_In = earlyCheckIn
+		booking.Late_Check_Out = lateCheckOut
+		booking.Subtotal_Price = subtotalPrice
+		booking.Discount = discount
+		booking.Total_Price = totalPrice
+		if err := store.UpdateBooking(ctx, &booking); err != nil {
+			return err
+		}
+		if priceChanged {
+			if err := store.SetBookingLineItems(ctx, booking.Id, lineItems); err != nil {
+				return err
+			}
+		}
+		if err := store.RemoveBookingRooms(ctx, booking.Id, removedRoomIds); err != nil {
+			return err
+		}
+		var bookingRooms []db.T_Booking_Rooms
+		for _, line := range lines {
+			if line.roomId != 0 && slices.Contains(addedRoomIds, line.roomId) {
+				bookingRooms = append(bookingRooms, db.T_Booking_Rooms{
+					Fk_Room_Id:    line.roomId,
+					Fk_Booking_id: booking.Id,
+					Adults:        guestsByRoom[line.roomId].Adults,
+					Children:      guestsByRoom[line.roomId].Children,
+					Amount:        line.amount,
+				})
+				continue
+			}
+			// Rooms kept on new dates are priced again
+			if !datesChanged {
+				continue
+			}
+			if line.roomId != 0 {
+				err = store.UpdateBookingRoomAmount(ctx, booking.Id, line.roomId, line.amount)
+			} else {
+				err = store.UpdateBookingRoomTypeAmount(ctx, booking.Id, line.roomTypeId, line.amount)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		if err := store.AddBookingRooms(ctx, bookingRooms); err != nil {
+			return err
+		}
+		for _, guests := range regrouped {
+			if err := store.UpdateBookingRoomGuests(ctx, booking.Id, guests.RoomId, guests.Adults, guests.Children); err != nil {
+				return err
+			}
+		}
+		return store.AddBookingStatusHistory(ctx, &db.T_Booking_Status_Histories{
+			Fk_Booking_Id: booking.Id,
+			From_Status:   booking.Status,
+			To_Status:     booking.Status,
+			Create_At:     time.Now().UTC(),
+			Note:          strings.Join(changes, "; "),
+		})
+	})
+	if err != nil {
+		if isConflict(err) {
+			server.metrics.bookingConflicts.Inc()
+		}
+		respondErr(ctx, err)
 		return
 	}
 
-	// Commit the transaction
-	tx.Commit()
-
-	ctx.JSON(http.StatusOK, booking)
+	respondOK(ctx, inUTC(booking))
 }
 
 type createHotelRequest struct {
-	Name        string  `form:"name" binding:"required"`
-	WardId      uint    `form:"wardId" binding:"required"`
-	DistrictId  uint    `form:"districtId" binding:"required"`
-	ProvinceId  uint    `form:"provinceId" binding:"required"`
-	Description string  `form:"description"`
-	Longitude   float64 `form:"longitude" binding:"required"`
-	Latitude    float64 `form:"latitude" binding:"required"`
-	Address     string  `form:"address" binding:"required"`
-	AgentId     uint    `form:"agentId" binding:"required"`
-	Type        string  `form:"type" binding:"required"`
-	AmenityIds  []uint  `form:"amenityIds" binding:"required"`
+	Name        string                  `form:"name" binding:"required,max=100"`
+	WardId      uint                    `form:"wardId" binding:"required"`
+	DistrictId  uint                    `form:"districtId" binding:"required"`
+	ProvinceId  uint                    `form:"provinceId" binding:"required"`
+	Description string                  `form:"description"`
+	Longitude   float64                 `form:"longitude" binding:"required,longitude"`
+	Latitude    float64                 `form:"latitude" binding:"required,latitude"`
+	Address     string                  `form:"address" binding:"required,max=255"`
+	AgentId     uint                    `form:"agentId" binding:"required"`
+	Type        string                  `form:"type" binding:"required,max=50"`
+	AmenityIds  []uint                  `form:"amenityIds" binding:"required,dive,required"`
+	Images      []*multipart.FileHeader `form:"images"`
+	// IANA time zone, the server default applies when empty
+	Timezone string `form:"timezone" binding:"omitempty,timezone"`
+	// "HH:MM" local times, the server defaults apply when check-in and
+	// check-out times are empty. Early check-in and late check-out are only
+	// offered when their time is set.
+	CheckInTime      string `form:"checkInTime" binding:"omitempty,datetime=15:04"`
+	CheckOutTime     string `form:"checkOutTime" binding:"omitempty,datetime=15:04"`
+	EarlyCheckInTime string `form:"earlyCheckInTime" binding:"omitempty,datetime=15:04"`
+	LateCheckOutTime string `form:"lateCheckOutTime" binding:"omitempty,datetime=15:04"`
 }
 
 type hotelResponse struct {
@@ -653,6 +1290,7 @@ type hotelResponse struct {
 	AgentId     uint    `json:"agentId"`
 	Status      string  `json:"status"`
 	Type        string  `json:"type"`
+	StayTimes
 }
 
 func (server *Server) createHotel(ctx *gin.Context) {
@@ -660,18 +1298,10 @@ func (server *Server) createHotel(ctx *gin.Context) {
 
 	// Parse the form data
 	if err := ctx.ShouldBind(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		respondInvalid(ctx, err)
 		return
 	}
 
-	// Handle image uploads
-	form, err := ctx.MultipartForm()
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
-		return
-	}
-	files := form.File["images"]
-
 	hotel := db.T_Properties{
 		Name:           req.Name,
 		Fk_Ward_Id:     req.WardId,
@@ -684,49 +1314,52 @@ func (server *Server) createHotel(ctx *gin.Context) {
 		Fk_Argent_Id:   req.AgentId,
 		Status:         "AVAILABLE",
 		Type:           req.Type,
+		Check_In_Time:  req.CheckInTime,
+		Check_Out_Time: req.CheckOutTime,
+		Timezone:       req.Timezone,
+	}
+	if req.EarlyCheckInTime != "" {
+		hotel.Early_Check_In_Time = &req.EarlyCheckInTime
+	}
+	if req.LateCheckOutTime != "" {
+		hotel.Late_Check_Out_Time = &req.LateCheckOutTime
+	}
+	policy, err := server.stays.policy(hotel)
+	if err == nil {
+		err = policy.checkOptions()
+	}
+	if err != nil {
+		respondInvalid(ctx, err)
+		return
 	}
 
 	// Create hotel record in the database
-	if err := server.store.Create(&hotel).Error; err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+	if err := server.store.CreateProperty(ctx, &hotel); err != nil {
+		respondInternalError(ctx, err)
 		return
 	}
 
 	// Save uploaded images
-	for _, file := range files {
-		filePath := fmt.Sprintf("uploads/%s", file.Filename) // Customize this path as needed
-
-		// Save the file locally
-		if err := ctx.SaveUploadedFile(file, filePath); err != nil {
-			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+	var imageUrls []string
+	for _, file := range req.Images {
+		url, err := server.saveUpload(ctx, file)
+		if err != nil {
+			respondUploadError(ctx, err)
 			return
 		}
+		imageUrls = append(imageUrls, url)
+	}
 
-		fullPath := fmt.Sprintf("%s/%s", utils.URL_API, filePath)
-		imagePath := &fullPath
-
-		// Create property image record in the database
-		propertyImage := db.T_Property_Images{
-			Url:            *imagePath,
-			Fk_Property_Id: hotel.Id,
-		}
-		if err := server.store.Create(&propertyImage).Error; err != nil {
-			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
-			return
-		}
+	// Create property image records in the database
+	if err := server.store.AddPropertyImages(ctx, hotel.Id, imageUrls); err != nil {
+		respondInternalError(ctx, err)
+		return
 	}
-	fmt.Println(">>>AmentiesIds", req.AmenityIds)
-	for _, amenityId := range req.AmenityIds {
-		propertyAmenity := db.T_Property_Amenities{
-			Fk_Property_Id: hotel.Id,
-			Fk_Amenity_Id:  amenityId,
-		}
-		if err := server.store.Create(&propertyAmenity).Error; err != nil {
-			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
-			return
-		}
+	if err := server.store.AddPropertyAmenities(ctx, hotel.Id, req.AmenityIds); err != nil {
+		respondInternalError(ctx, err)
+		return
 	}
-	ctx.JSON(http.StatusOK, hotelResponse{
+	respondOK(ctx, hotelResponse{
 		Id:          hotel.Id,
 		Name:        hotel.Name,
 		WardId:      hotel.Fk_Ward_Id,
@@ -739,6 +1372,7 @@ func (server *Server) createHotel(ctx *gin.Context) {
 		AgentId:     hotel.Fk_Argent_Id,
 		Status:      hotel.Status,
 		Type:        hotel.Type,
+		StayTimes:   policy.times(),
 	})
 }
 
@@ -748,24 +1382,21 @@ func (server *Server) deleteRoom(ctx *gin.Context) {
 	// Check if room ID is valid
 	id, err := strconv.Atoi(roomID)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid room ID"})
+		respondInvalid(ctx, FieldError{Field: "roomId", Rule: "id"})
 		return
 	}
-
-	// Start a transaction
-	tx := server.store.Begin()
+	annotateSpan(ctx, attribute.Int64("room.id", int64(id)))
 
 	// Update room status to DELETED
-	if err := tx.Model(&db.T_Rooms{}).Where("id = ?", id).Update("status", "DELETED").Error; err != nil {
-		tx.Rollback()
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete room"})
+	if err := server.store.UpdateRoomStatus(ctx, uint(id), utils.RoomStatusDeleted); err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			err = errRoomNotFound
+		}
+		respondErr(ctx, err)
 		return
 	}
 
-	// Commit the transaction
-	tx.Commit()
-
-	ctx.JSON(http.StatusOK, gin.H{"message": "Room deleted successfully"})
+	respondMessage(ctx, "Room deleted successfully", nil)
 }
 func (server *Server) deleteHotel(ctx *gin.Context) {
 	hotelID := ctx.Param("hotelId")
@@ -773,40 +1404,38 @@ func (server *Server) deleteHotel(ctx *gin.Context) {
 	// Check if hotel ID is valid
 	id, err := strconv.Atoi(hotelID)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid hotel ID"})
+		respondInvalid(ctx, FieldError{Field: "hotelId", Rule: "id"})
 		return
 	}
-
-	// Start a transaction
-	tx := server.store.Begin()
+	annotateSpan(ctx, attribute.Int64("property.id", int64(id)))
 
 	// Update hotel status to DELETED
-	if err := tx.Model(&db.T_Properties{}).Where("id = ?", id).Update("status", "DELETED").Error; err != nil {
-		tx.Rollback()
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete hotel"})
+	if err := server.store.UpdatePropertyStatus(ctx, uint(id), utils.HotelStatusDeleted); err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			err = errHotelNotFound
+		}
+		respondErr(ctx, err)
 		return
 	}
 
-	// Commit the transaction
-	tx.Commit()
-
-	ctx.JSON(http.StatusOK, gin.H{"message": "Hotel deleted successfully"})
+	respondMessage(ctx, "Hotel deleted successfully", nil)
 }
 
 // HotelResponse struct for hotel (property) response
 type HotelResponse struct {
-	ID             uint              `json:"id"`
-	Name           string            `json:"name"`
-	WardID         uint              `json:"wardId"`
-	DistrictID     uint              `json:"districtId"`
-	ProvinceID     uint              `json:"provinceId"`
-	Description    *string           `json:"description"`
-	Longitude      *float64          `json:"longitude"`
-	Latitude       *float64          `json:"latitude"`
-	Address        string            `json:"address"`
-	AgentID        uint              `json:"agentId"`
-	Status         string            `json:"status"`
-	Type           string            `json:"type"`
+	ID          uint     `json:"id"`
+	Name        string   `json:"name"`
+	WardID      uint     `json:"wardId"`
+	DistrictID  uint     `json:"districtId"`
+	ProvinceID  uint     `json:"provinceId"`
+	Description *string  `json:"description"`
+	Longitude   *float64 `json:"longitude"`
+	Latitude    *float64 `json:"latitude"`
+	Address     string   `json:"address"`
+	AgentID     uint     `json:"agentId"`
+	Status      string   `json:"status"`
+	Type        string   `json:"type"`
+	StayTimes
 	HotelAmenities []AmenityResponse `json:"amenities"`
 	HotelImages    []ImageResponse   `json:"images"`
 	HotelRooms     []RoomResponse    `json:"rooms"`
@@ -816,9 +1445,13 @@ type HotelResponse struct {
 type RoomResponse struct {
 	ID            uint              `json:"id"`
 	PropertyID    uint              `json:"propertyId"`
+	RoomTypeID    *uint             `json:"roomTypeId"`
 	Name          string            `json:"name"`
 	Status        string            `json:"status"`
 	Price         uint              `json:"price"`
+	MaxAdults     uint              `json:"maxAdults"`
+	MaxChildren   uint              `json:"maxChildren"`
+	Beds          uint              `json:"beds"`
 	RoomAmenities []AmenityResponse `json:"amenities"`
 	RoomImages    []ImageResponse   `json:"images"`
 }
@@ -839,88 +1472,86 @@ type ImageResponse struct {
 func (server *Server) getHotelsByAgent(ctx *gin.Context) {
 	agentID, err := strconv.Atoi(ctx.Param("agentId"))
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid agent ID"})
+		respondInvalid(ctx, FieldError{Field: "agentId", Rule: "id"})
 		return
 	}
 
 	var hotels = []HotelResponse{}
 
 	// Query properties for the given agentId
-	var properties []db.T_Properties
-	if err := server.store.Where("fk_argent_id = ? AND status <> ?", agentID, "DELETED").Find(&properties).Error; err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching hotels"})
+	properties, err := server.store.ListPropertiesByAgent(ctx, uint(agentID), utils.HotelStatusDeleted)
+	if err != nil {
+		respondInternalError(ctx, err)
 		return
 	}
+	if len(properties) == 0 {
+		respondOK(ctx, hotels)
+		return
+	}
+
+	propertyIds := make([]uint, 0, len(properties))
+	for _, property := range properties {
+		propertyIds = append(propertyIds, property.Id)
+	}
+
+	// Query rooms of all hotels at once
+	dbRooms, err := server.store.ListRoomsByProperties(ctx, propertyIds, utils.RoomStatusDeleted)
+	if err != nil {
+		respondInternalError(ctx, err)
+		return
+	}
+	rooms, err := server.buildRoomResponses(ctx, dbRooms)
+	if err != nil {
+		respondInternalError(ctx, err)
+		return
+	}
+	roomsByProperty := map[uint][]RoomResponse{}
+	for _, room := range rooms {
+		roomsByProperty[room.PropertyID] = append(roomsByProperty[room.PropertyID], room)
+	}
+
+	hotelImages, err := server.store.ListPropertyImages(ctx, propertyIds)
+	if err != nil {
+		respondInternalError(ctx, err)
+		return
+	}
+	imagesByProperty := map[uint][]ImageResponse{}
+	for _, image := range hotelImages {
+		imagesByProperty[image.Fk_Property_Id] = append(imagesByProperty[image.Fk_Property_Id], ImageResponse{
+			ID:  image.Id,
+			Url: image.Url,
+		})
+	}
+
+	hotelAmenities, err := server.store.ListPropertyAmenities(ctx, propertyIds)
+	if err != nil {
+		respondInternalError(ctx, err)
+		return
+	}
+	amenitiesByProperty := map[uint][]AmenityResponse{}
+	for _, amenity := range hotelAmenities {
+		amenitiesByProperty[amenity.Fk_Property_Id] = append(amenitiesByProperty[amenity.Fk_Property_Id], AmenityResponse{
+			ID:   amenity.Id,
+			Name: amenity.Name,
+			Type: amenity.Type,
+		})
+	}
 
-	// Iterate through each property (hotel)
 	for _, property := range properties {
-		var rooms = []RoomResponse{}
-
-		// Query rooms for the current hotel
-		var dbRooms []db.T_Rooms
-		if err := server.store.Where("fk_property_id = ? AND status != ?", property.Id, "DELETED").Find(&dbRooms).Error; err != nil {
-			fmt.Println("ERR", err)
-			continue // Skip this property if rooms cannot be fetched
-		}
-
-		// Map database rooms to RoomResponse structs
-		for _, dbRoom := range dbRooms {
-			room := RoomResponse{
-				ID:         dbRoom.Id,
-				PropertyID: dbRoom.Fk_Property_Id,
-				Name:       dbRoom.Name,
-				Status:     dbRoom.Status,
-				Price:      dbRoom.Price,
-			}
-
-			// Query room amenities
-			var roomAmenities []AmenityResponse
-			if err := server.store.Table("t_room_amenities").
-				Select("t_amenities.id, t_amenities.name , t_amenities.type").
-				Joins("JOIN t_amenities ON t_amenities.id = t_room_amenities.fk_amenity_id").
-				Where("t_amenities.is_deleted = ?", false).
-				Where("t_room_amenities.fk_room_id = ?", dbRoom.Id).
-				Find(&roomAmenities).Error; err != nil {
-				continue // Skip this room if room amenities cannot be fetched
-			}
-			fmt.Printf("%+v \n", roomAmenities)
-			room.RoomAmenities = roomAmenities
-
-			// Query room images
-			var roomImages []ImageResponse
-			if err := server.store.Table("t_room_images").
-				Select("t_room_images.id, t_room_images.url ").
-				Where("t_room_images.fk_room_id = ?", dbRoom.Id).
-				Find(&roomImages).Error; err != nil {
-				continue // Skip this	 room if room amenities cannot be fetched
-			}
-			room.RoomImages = roomImages
-			fmt.Printf("ROOM%+v \n", roomAmenities)
-
-			// Append room to rooms list
-			rooms = append(rooms, room)
-		}
-		var hotelImages []ImageResponse
-		if err := server.store.Table("t_property_images").
-			Select("t_property_images.id, t_property_images.url ").
-			Where("t_property_images.fk_property_id = ?", property.Id).
-			Find(&hotelImages).Error; err != nil {
-			continue // Skip this room if room amenities cannot be fetched
-		}
-		var hotelAmenities []AmenityResponse
-		if err := server.store.Table("t_property_amenities").
-			Select("t_amenities.id, t_amenities.name , t_amenities.type").
-			Joins("JOIN t_amenities ON t_amenities.id = t_property_amenities.fk_amenity_id").
-			Where("t_amenities.is_deleted = ?", false).
-			Where("t_property_amenities.fk_property_id = ?", property.Id).
-			Find(&hotelAmenities).Error; err != nil {
-			continue // Skip this room if room amenities cannot be fetched
+		hotelRooms := roomsByProperty[property.Id]
+		if hotelRooms == nil {
+			hotelRooms = []RoomResponse{}
 		}
 		// Prepare hotel response
+		policy, err := server.stays.policy(property)
+		if err != nil {
+			respondInternalError(ctx, err)
+			return
+		}
 		hotel := HotelResponse{
 			ID:             property.Id,
 			Name:           property.Name,
-			WardID:         property.Fk_Argent_Id,
+			WardID:         property.Fk_Ward_Id,
 			DistrictID:     property.Fk_District_Id,
 			ProvinceID:     property.Fk_Province_Id,
 			Description:    &property.Description.String,
@@ -930,9 +1561,10 @@ func (server *Server) getHotelsByAgent(ctx *gin.Context) {
 			AgentID:        property.Fk_Argent_Id,
 			Status:         property.Status,
 			Type:           property.Type,
-			HotelAmenities: hotelAmenities, // Populate if needed
-			HotelImages:    hotelImages,    // Populate if needed
-			HotelRooms:     rooms,
+			StayTimes:      policy.times(),
+			HotelAmenities: amenitiesByProperty[property.Id],
+			HotelImages:    imagesByProperty[property.Id],
+			HotelRooms:     hotelRooms,
 		}
 
 		// Append hotel to hotels list
@@ -940,5 +1572,5 @@ func (server *Server) getHotelsByAgent(ctx *gin.Context) {
 	}
 
 	// Return JSON response with the list of hotels
-	ctx.JSON(http.StatusOK, gin.H{"hotels": hotels})
+	respondOK(ctx, hotels)
 }
@@ -0,0 +1,292 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/lancer2672/BookingAppSubServer/db"
+	"github.com/lancer2672/BookingAppSubServer/internal/utils"
+)
+
+func TestCreateHotel(t *testing.T) {
+	server, store := newTestServer(t)
+	amenity := db.T_Amenities{Name: "Wifi"}
+	store.CreateAmenity(&amenity)
+
+	status, response := serveForm(t, server, http.MethodPost, "/api/hotels", map[string][]string{
+		"name":       {"Riverside"},
+		"wardId":     {"1"},
+		"districtId": {"2"},
+		"provinceId": {"3"},
+		"longitude":  {"106.7"},
+		"latitude":   {"10.8"},
+		"address":    {"1 Nguyen Hue"},
+		"agentId":    {"7"},
+		"type":       {"HOTEL"},
+		"amenityIds": {fmt.Sprint(amenity.Id)},
+	})
+	if status != http.StatusOK {
+		t.Fatalf("create: got %d %+v", status, response.Error)
+	}
+	var created hotelResponse
+	decodeData(t, response, &created)
+
+	status, response = serveJSON(t, server, http.MethodGet, "/api/hotels/7", nil)
+	if status != http.StatusOK {
+		t.Fatalf("list: got %d %+v", status, response.Error)
+	}
+	var hotels []HotelResponse
+	decodeData(t, response, &hotels)
+	if len(hotels) != 1 || hotels[0].ID != created.Id || hotels[0].Name != "Riverside" {
+		t.Fatalf("list: got %+v", hotels)
+	}
+}
+
+func TestCreateHotelInvalid(t *testing.T) {
+	server, _ := newTestServer(t)
+
+	status, response := serveForm(t, server, http.MethodPost, "/api/hotels", map[string][]string{
+		"name":     {"Riverside"},
+		"latitude": {"123"},
+	})
+	expectError(t, status, response, http.StatusBadRequest, CodeValidationFailed)
+}
+
+func TestDeleteHotel(t *testing.T) {
+	server, store := newTestServer(t)
+	property := createTestProperty(t, store, 7)
+
+	status, response := serveJSON(t, server, http.MethodDelete, fmt.Sprintf("/api/hotels/%d", property.Id), nil)
+	if status != http.StatusOK {
+		t.Fatalf("delete: got %d %+v", status, response.Error)
+	}
+	status, response = serveJSON(t, server, http.MethodGet, "/api/hotels/7", nil)
+	var hotels []HotelResponse
+	decodeData(t, response, &hotels)
+	if status != http.StatusOK || len(hotels) != 0 {
+		t.Fatalf("list after delete: got %d %+v", status, hotels)
+	}
+
+	status, response = serveJSON(t, server, http.MethodDelete, "/api/hotels/999", nil)
+	expectError(t, status, response, http.StatusNotFound, CodeHotelNotFound)
+}
+
+// bookRoom books room for the nights from days to days+nights from today.
+func bookRoom(t *testing.T, server *Server, room db.T_Rooms, days, nights int) (int, testResponse) {
+	t.Helper()
+	return serveJSON(t, server, http.MethodPost, "/api/booking/v2", map[string]any{
+		"userId":     3,
+		"propertyId": room.Fk_Property_Id,
+		"roomIds":    []uint{room.Id},
+		"startDate":  localDate(days),
+		"endDate":    localDate(days + nights),
+	})
+}
+
+func TestCreateBooking(t *testing.T) {
+	server, store := newTestServer(t)
+	property := createTestProperty(t, store, 7)
+	room := createTestRoom(t, store, property.Id, 500)
+
+	status, response := bookRoom(t, server, room, 10, 3)
+	if status != http.StatusOK {
+		t.Fatalf("create: got %d %+v", status, response.Error)
+	}
+	var booking db.T_Bookings
+	decodeData(t, response, &booking)
+	if booking.Status != utils.BookingStatus_Confirmed || booking.Nights != 3 || booking.Total_Price != 1500 {
+		t.Fatalf("create: got %+v", booking)
+	}
+
+	status, response = serveJSON(t, server, http.MethodGet, fmt.Sprintf("/api/bookings/%d", booking.Id), nil)
+	if status != http.StatusOK {
+		t.Fatalf("get: got %d %+v", status, response.Error)
+	}
+	var detail BookingDetailResponse
+	decodeData(t, response, &detail)
+	if len(detail.Rooms) != 1 || detail.Rooms[0].Id != room.Id || len(detail.StatusHistory) != 1 {
+		t.Fatalf("get: got %+v", detail)
+	}
+}
+
+func TestCreateBookingConflicts(t *testing.T) {
+	server, store := newTestServer(t)
+	property := createTestProperty(t, store, 7)
+	room := createTestRoom(t, store, property.Id, 500)
+
+	if status, response := bookRoom(t, server, room, 10, 3); status != http.StatusOK {
+		t.Fatalf("first booking: got %d %+v", status, response.Error)
+	}
+	status, response := bookRoom(t, server, room, 12, 2)
+	expectError(t, status, response, http.StatusConflict, CodeRoomAlreadyBooked)
+
+	// Back to back stays do not overlap
+	if status, response := bookRoom(t, server, room, 13, 2); status != http.StatusOK {
+		t.Fatalf("next stay: got %d %+v", status, response.Error)
+	}
+
+	room.Id = 999
+	status, response = bookRoom(t, server, room, 20, 1)
+	expectError(t, status, response, http.StatusNotFound, CodeRoomNotFound)
+}
+
+func TestUpdateBookingStatus(t *testing.T) {
+	server, store := newTestServer(t)
+	property := createTestProperty(t, store, 7)
+	room := createTestRoom(t, store, property.Id, 500)
+	_, response := bookRoom(t, server, room, 10, 1)
+	var booking db.T_Bookings
+	decodeData(t, response, &booking)
+
+	status, response := serveJSON(t, server, http.MethodPatch, "/api/bookings", map[string]any{
+		"bookingId": booking.Id,
+		"status":    utils.BookingStatus_Canceled,
+	})
+	if status != http.StatusOK {
+		t.Fatalf("cancel: got %d %+v", status, response.Error)
+	}
+	decodeData(t, response, &booking)
+	if booking.Status != utils.BookingStatus_Canceled {
+		t.Fatalf("cancel: got status %s", booking.Status)
+	}
+	// A canceled booking no longer holds its room
+	if status, response := bookRoom(t, server, room, 10, 1); status != http.StatusOK {
+		t.Fatalf("rebook: got %d %+v", status, response.Error)
+	}
+
+	status, response = serveJSON(t, server, http.MethodPatch, "/api/bookings", map[string]any{
+		"bookingId": 999,
+		"status":    utils.BookingStatus_Canceled,
+	})
+	expectError(t, status, response, http.StatusNotFound, CodeBookingNotFound)
+
+	status, response = serveJSON(t, server, http.MethodPatch, "/api/bookings", map[string]any{
+		"bookingId": booking.Id,
+		"status":    "LOST",
+	})
+	expectError(t, status, response, http.StatusBadRequest, CodeValidationFailed)
+}
+
+func TestListBookings(t *testing.T) {
+	server, store := newTestServer(t)
+	property := createTestProperty(t, store, 7)
+	for i := 0; i < 3; i++ {
+		room := createTestRoom(t, store, property.Id, 500)
+		if status, response := bookRoom(t, server, room, 10, 1); status != http.StatusOK {
+			t.Fatalf("booking %d: got %d %+v", i, status, response.Error)
+		}
+	}
+
+	status, response := serveJSON(t, server, http.MethodGet, "/api/bookings/user/3?limit=2", nil)
+	if status != http.StatusOK {
+		t.Fatalf("first page: got %d %+v", status, response.Error)
+	}
+	var bookings []BookingResponse
+	decodeData(t, response, &bookings)
+	if len(bookings) != 2 || response.Paging == nil || !response.Paging.HasMore {
+		t.Fatalf("first page: got %d bookings, paging %+v", len(bookings), response.Paging)
+	}
+
+	status, response = serveJSON(t, server, http.MethodGet, "/api/bookings/user/3?limit=2&cursor="+response.Paging.NextCursor, nil)
+	if status != http.StatusOK {
+		t.Fatalf("second page: got %d %+v", status, response.Error)
+	}
+	decodeData(t, response, &bookings)
+	if len(bookings) != 1 || response.Paging.HasMore {
+		t.Fatalf("second page: got %d bookings, paging %+v", len(bookings), response.Paging)
+	}
+
+	status, response = serveJSON(t, server, http.MethodGet, "/api/bookings/agent/7", nil)
+	decodeData(t, response, &bookings)
+	if status != http.StatusOK || len(bookings) != 3 {
+		t.Fatalf("agent list: got %d with %d bookings", status, len(bookings))
+	}
+
+	status, response = serveJSON(t, server, http.MethodGet, "/api/bookings/agent/7?status=pending,confirmed", nil)
+	decodeData(t, response, &bookings)
+	if status != http.StatusOK || len(bookings) != 3 {
+		t.Fatalf("status filter: got %d with %d bookings", status, len(bookings))
+	}
+
+	status, response = serveJSON(t, server, http.MethodGet, "/api/bookings/agent/7?status=CONFIRMED,PAID", nil)
+	expectError(t, status, response, http.StatusBadRequest, CodeValidationFailed)
+	if details := response.Error.Details; len(details) != 1 || details[0].Field != "status" || details[0].Rule != "oneof" {
+		t.Fatalf("status filter: got %+v", details)
+	}
+
+	status, response = serveJSON(t, server, http.MethodGet, "/api/bookings/user/abc", nil)
+	expectError(t, status, response, http.StatusBadRequest, CodeValidationFailed)
+}
+
+func TestCreateBookingRoomsOfProperty(t *testing.T) {
+	server, store := newTestServer(t)
+	property := createTestProperty(t, store, 7)
+	other := createTestProperty(t, store, 8)
+	room := createTestRoom(t, store, property.Id, 500)
+	otherRoom := createTestRoom(t, store, other.Id, 100)
+
+	// A room of another property cannot be priced under this one
+	status, response := serveJSON(t, server, http.MethodPost, "/api/booking/v2", map[string]any{
+		"userId":     3,
+		"propertyId": property.Id,
+		"roomIds":    []uint{room.Id, otherRoom.Id},
+		"startDate":  localDate(10),
+		"endDate":    localDate(11),
+	})
+	expectError(t, status, response, http.StatusUnprocessableEntity, CodeRoomNotInHotel)
+
+	status, response = serveJSON(t, server, http.MethodPost, "/api/booking/v2", map[string]any{
+		"userId":     3,
+		"propertyId": property.Id,
+		"roomIds":    []uint{room.Id, room.Id},
+		"startDate":  localDate(10),
+		"endDate":    localDate(11),
+	})
+	expectError(t, status, response, http.StatusBadRequest, CodeValidationFailed)
+	if len(response.Error.Details) != 1 || response.Error.Details[0].Field != "roomIds" {
+		t.Fatalf("duplicate rooms: got fields %+v", response.Error.Details)
+	}
+}
+
+// queryCount returns the number of store calls made serving GET path.
+func queryCount(t *testing.T, server *Server, store *db.MemoryStore, path string) int64 {
+	t.Helper()
+	before := store.Queries()
+	status, response := serveJSON(t, server, http.MethodGet, path, nil)
+	if status != http.StatusOK {
+		t.Fatalf("%s: got %d %+v", path, status, response.Error)
+	}
+	return store.Queries() - before
+}
+
+// TestListQueries checks that listing bookings and hotels loads related
+// rows in batches, with a number of queries that does not grow with the
+// number of bookings, properties and rooms listed.
+func TestListQueries(t *testing.T) {
+	paths := []string{"/api/bookings/agent/7?limit=100", "/api/bookings/user/3?limit=100", "/api/hotels/7"}
+	counts := func(size int) []int64 {
+		server, store := newTestServer(t)
+		for i := 0; i < size; i++ {
+			property := createTestProperty(t, store, 7)
+			for j := 0; j < size; j++ {
+				room := createTestRoom(t, store, property.Id, 500)
+				if status, response := bookRoom(t, server, room, 10, 1); status != http.StatusOK {
+					t.Fatalf("booking: got %d %+v", status, response.Error)
+				}
+			}
+		}
+		var counts []int64
+		for _, path := range paths {
+			counts = append(counts, queryCount(t, server, store, path))
+		}
+		return counts
+	}
+
+	small, large := counts(1), counts(4)
+	for i, path := range paths {
+		if small[i] != large[i] {
+			t.Errorf("%s: %d queries for 1 booking, %d for 16", path, small[i], large[i])
+		}
+	}
+}
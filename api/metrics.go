@@ -0,0 +1,102 @@
+package api
+
+import (
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const metricsNamespace = "bookingapp"
+
+// metrics holds the Prometheus collectors of a server. Each server has its
+// own registry so several servers, e.g. in tests, never collide.
+type metrics struct {
+	registry *prometheus.Registry
+
+	requestDuration  *prometheus.HistogramVec
+	bookingsCreated  *prometheus.CounterVec
+	bookingConflicts prometheus.Counter
+	depositsCreated  prometheus.Counter
+	uploadedFiles    prometheus.Counter
+	uploadedBytes    prometheus.Counter
+}
+
+func newMetrics() *metrics {
+	m := &metrics{
+		registry: prometheus.NewRegistry(),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Duration of HTTP requests by route and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		bookingsCreated: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "bookings_created_total",
+			Help:      "Bookings created, by initial status.",
+		}, []string{"status"}),
+		bookingConflicts: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "booking_conflicts_total",
+			Help:      "Booking creations and changes rejected with 409, such as for a room already booked or a promotion code fully redeemed.",
+		}),
+		depositsCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "booking_deposits_total",
+			Help:      "Deposits recorded with a booking.",
+		}),
+		uploadedFiles: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "uploaded_files_total",
+			Help:      "Files stored through the upload endpoints.",
+		}),
+		uploadedBytes: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "uploaded_bytes_total",
+			Help:      "Bytes stored through the upload endpoints.",
+		}),
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requestDuration,
+		m.bookingsCreated,
+		m.bookingConflicts,
+		m.depositsCreated,
+		m.uploadedFiles,
+		m.uploadedBytes,
+	)
+	return m
+}
+
+// observeRequests records the duration of every request. Requests that
+// match no route share one label so unknown paths cannot blow up the
+// number of series.
+func (m *metrics) observeRequests() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		ctx.Next()
+
+		route := ctx.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		m.requestDuration.
+			WithLabelValues(ctx.Request.Method, route, strconv.Itoa(ctx.Writer.Status())).
+			Observe(time.Since(start).Seconds())
+	}
+}
+
+func (m *metrics) handler() gin.HandlerFunc {
+	return gin.WrapH(promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+}
+
+// MonitorDatabase exports the connection pool statistics of conn.
+func (server *Server) MonitorDatabase(conn *sql.DB) error {
+	return server.metrics.registry.Register(collectors.NewDBStatsCollector(conn, "booking"))
+}
@@ -0,0 +1,172 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+	"github.com/lancer2672/BookingAppSubServer/internal/tracing"
+	"github.com/lancer2672/BookingAppSubServer/internal/utils"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// corsMiddleware allows the origins in config.CORSAllowedOrigins. When none
+// are configured, development and test allow every origin while staging and
+// production only serve same-origin requests.
+func corsMiddleware(config utils.Config) gin.HandlerFunc {
+	origins := config.CORSAllowedOrigins
+	if len(origins) == 0 {
+		switch config.Environment {
+		case utils.EnvironmentDevelopment, utils.EnvironmentTest:
+			origins = []string{"*"}
+		default:
+			log.Warn().Msg("CORS_ALLOWED_ORIGINS is empty, cross-origin requests are rejected")
+			return func(ctx *gin.Context) { ctx.Next() }
+		}
+	}
+
+	corsConfig := cors.Config{
+		AllowMethods:     config.CORSAllowedMethods,
+		AllowHeaders:     config.CORSAllowedHeaders,
+		ExposeHeaders:    []string{requestIDHeader},
+		AllowCredentials: config.CORSAllowCredentials,
+		MaxAge:           config.CORSMaxAge,
+	}
+	for _, origin := range origins {
+		if origin == "*" {
+			corsConfig.AllowAllOrigins = true
+			corsConfig.AllowCredentials = false
+			corsConfig.AllowOrigins = nil
+			break
+		}
+		corsConfig.AllowOrigins = append(corsConfig.AllowOrigins, strings.TrimRight(origin, "/"))
+	}
+	return cors.New(corsConfig)
+}
+
+// securityHeaders adds the standard hardening headers to every response.
+// HSTS is only sent outside development and test, where the server is
+// expected to sit behind TLS.
+func securityHeaders(config utils.Config) gin.HandlerFunc {
+	hsts := config.Environment != utils.EnvironmentDevelopment && config.Environment != utils.EnvironmentTest
+	return func(ctx *gin.Context) {
+		header := ctx.Writer.Header()
+		header.Set("X-Content-Type-Options", "nosniff")
+		header.Set("X-Frame-Options", "DENY")
+		header.Set("Referrer-Policy", "strict-origin-when-cross-origin")
+		header.Set("Content-Security-Policy", "default-src 'none'; frame-ancestors 'none'")
+		header.Set("Cross-Origin-Opener-Policy", "same-origin")
+		if hsts {
+			header.Set("Strict-Transport-Security", "max-age=63072000; includeSubDomains")
+		}
+		ctx.Next()
+	}
+}
+
+const (
+	requestIDHeader = "X-Request-ID"
+	// requestUserKey holds the ID of the user a request acts for, see
+	// setRequestUser.
+	requestUserKey = "requestUserId"
+)
+
+// quietRoutes are polled by infrastructure; their successful requests are
+// only logged at debug level.
+var quietRoutes = map[string]bool{
+	"/livez":       true,
+	"/readyz":      true,
+	"/healthcheck": true,
+	"/metrics":     true,
+}
+
+// requestLogger gives every request an ID, taken from the X-Request-ID
+// header when the caller sent a sane one, and a zerolog logger carrying it.
+// The logger is stored in the request context, so handlers and the SQL
+// logger find it with zerolog.Ctx. One line is logged per request once it
+// has been served.
+func requestLogger() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+
+		requestID := ctx.GetHeader(requestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = newRequestID()
+		}
+		ctx.Header(requestIDHeader, requestID)
+
+		logContext := log.With().Str("request_id", requestID)
+		if traceID := tracing.TraceID(ctx.Request.Context()); traceID != "" {
+			logContext = logContext.Str("trace_id", traceID)
+		}
+		logger := logContext.Logger()
+		ctx.Request = ctx.Request.WithContext(logger.WithContext(ctx.Request.Context()))
+
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+		var event *zerolog.Event
+		switch {
+		case status >= http.StatusInternalServerError:
+			event = logger.Error()
+		case status >= http.StatusBadRequest:
+			event = logger.Warn()
+		case quietRoutes[ctx.FullPath()]:
+			event = logger.Debug()
+		default:
+			event = logger.Info()
+		}
+		if userId, ok := ctx.Get(requestUserKey); ok {
+			event = event.Interface("user_id", userId)
+		}
+		if len(ctx.Errors) > 0 {
+			event = event.Strs("errors", ctx.Errors.Errors())
+		}
+		event.Str("method", ctx.Request.Method).
+			Str("route", ctx.FullPath()).
+			Str("path", ctx.Request.URL.Path).
+			Int("status", status).
+			Dur("latency", time.Since(start)).
+			Str("client_ip", ctx.ClientIP()).
+			Int("size", ctx.Writer.Size()).
+			Msg("request")
+	}
+}
+
+// setRequestUser records which user the request acts for, both in the
+// request log line and in the logger used for the rest of the request.
+func setRequestUser(ctx *gin.Context, userId uint) {
+	ctx.Set(requestUserKey, userId)
+	logger := zerolog.Ctx(ctx.Request.Context()).With().Uint("user_id", userId).Logger()
+	ctx.Request = ctx.Request.WithContext(logger.WithContext(ctx.Request.Context()))
+}
+
+// annotateSpan adds attributes, such as the IDs of the booking or property
+// a request works on, to the span of the request.
+func annotateSpan(ctx *gin.Context, attributes ...attribute.KeyValue) {
+	trace.SpanFromContext(ctx.Request.Context()).SetAttributes(attributes...)
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, r := range id {
+		if r < '!' || r > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
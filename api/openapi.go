@@ -0,0 +1,669 @@
+package api
+
+import (
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lancer2672/BookingAppSubServer/db"
+)
+
+// routeDoc describes one route for the OpenAPI document. Request and
+// response shapes are given as zero values of the types the handler binds
+// and responds with; the schemas are derived from their tags.
+type routeDoc struct {
+	Summary string
+	Tag     string
+	// Query, Body and Form are bound with ShouldBindQuery, ShouldBindJSON
+	// and ShouldBind (multipart) respectively
+	Query any
+	Body  any
+	Form  any
+	// Data is the Data of the response envelope
+	Data  any
+	Paged bool
+	// Raw is the content type of a response that is not an envelope, such
+	// as files or metrics
+	Raw string
+	// Errors lists the statuses the route answers with an ErrorBody besides
+	// 500, which every route may answer
+	Errors     []int
+	Deprecated bool
+	// Unavailable routes answer 503 with the same envelope as 200
+	Unavailable bool
+}
+
+// routeDocs documents every route of setupRouter, keyed by method and full
+// path. TestRouteDocs fails when a route is missing, so the document
+// cannot silently drift from the router.
+var routeDocs = map[string]routeDoc{
+	"GET /uploads/*filepath": {
+		Summary: "Download an uploaded file",
+		Tag:     "uploads",
+		Raw:     "application/octet-stream",
+		Errors:  []int{http.StatusNotFound},
+	},
+	"HEAD /uploads/*filepath": {
+		Summary: "Check an uploaded file",
+		Tag:     "uploads",
+		Raw:     "application/octet-stream",
+		Errors:  []int{http.StatusNotFound},
+	},
+	"GET /openapi.json": {
+		Summary: "OpenAPI document of this API",
+		Tag:     "operations",
+		Raw:     "application/json",
+	},
+	"GET /docs": {
+		Summary: "API documentation",
+		Tag:     "operations",
+		Raw:     "text/html",
+	},
+	"GET /metrics": {
+		Summary: "Prometheus metrics",
+		Tag:     "operations",
+		Raw:     "text/plain",
+	},
+	"GET /livez": {
+		Summary: "Liveness probe",
+		Tag:     "operations",
+		Data:    HealthReport{},
+	},
+	"GET /readyz": {
+		Summary:     "Readiness probe",
+		Tag:         "operations",
+		Data:        HealthReport{},
+		Unavailable: true,
+	},
+	"GET /healthcheck": {
+		Summary:    "Liveness probe answering with the JSON string \"OK\", use /livez",
+		Tag:        "operations",
+		Raw:        "application/json",
+		Deprecated: true,
+	},
+
+	"POST /api/booking/v2": {
+		Summary: "Book rooms of a property",
+		Tag:     "bookings",
+		Body:    bookingRequest{},
+		Data:    db.T_Bookings{},
+		Errors:  []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity},
+	},
+	"PATCH /api/bookings": {
+		Summary: "Change the status of a booking",
+		Tag:     "bookings",
+		Body:    updateStatusRequest{},
+		Data:    db.T_Bookings{},
+		Errors:  []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity},
+	},
+	"GET /api/bookings/user/:userId": {
+		Summary: "List the bookings of a user",
+		Tag:     "bookings",
+		Query:   listBookingsRequest{},
+		Data:    []BookingResponse{},
+		Paged:   true,
+		Errors:  []int{http.StatusBadRequest},
+	},
+	"GET /api/bookings/agent/:agentId": {
+		Summary: "List the bookings of the properties of an agent",
+		Tag:     "bookings",
+		Query:   listBookingsRequest{},
+		Data:    []BookingResponse{},
+		Paged:   true,
+		Errors:  []int{http.StatusBadRequest, http.StatusNotFound},
+	},
+	"GET /api/bookings/:bookingId": {
+		Summary: "Get a booking with its guest, history and payment",
+		Tag:     "bookings",
+		Data:    BookingDetailResponse{},
+		Errors:  []int{http.StatusBadRequest, http.StatusNotFound},
+	},
+	"PATCH /api/bookings/:bookingId": {
+		Summary: "Change the dates, rooms or check-in options of a booking",
+		Tag:     "bookings",
+		Body:    modifyBookingRequest{},
+		Data:    db.T_Bookings{},
+		Errors:  []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity},
+	},
+
+	"POST /api/hotels": {
+		Summary: "Create a hotel",
+		Tag:     "hotels",
+		Form:    createHotelRequest{},
+		Data:    hotelResponse{},
+		Errors:  []int{http.StatusBadRequest, http.StatusRequestEntityTooLarge},
+	},
+	"DELETE /api/hotels/:hotelId": {
+		Summary: "Delete a hotel",
+		Tag:     "hotels",
+		Errors:  []int{http.StatusBadRequest, http.StatusNotFound},
+	},
+	"GET /api/hotels/:agentId": {
+		Summary: "List the hotels of an agent",
+		Tag:     "hotels",
+		Data:    []HotelResponse{},
+		Errors:  []int{http.StatusBadRequest},
+	},
+
+	"GET /api/rooms/:propertyId": {
+		Summary: "List the rooms of a hotel",
+		Tag:     "rooms",
+		Data:    []RoomResponse{},
+		Errors:  []int{http.StatusBadRequest},
+	},
+	"POST /api/rooms/": {
+		Summary: "Create a room",
+		Tag:     "rooms",
+		Form:    createRoomRequest{},
+		Data:    RoomResponse{},
+		Errors:  []int{http.StatusBadRequest, http.StatusNotFound, http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity},
+	},
+	"POST /api/room-types": {
+		Summary: "Create a room type, whose units are then created as rooms",
+		Tag:     "rooms",
+		Form:    createRoomTypeRequest{},
+		Data:    RoomTypeResponse{},
+		Errors:  []int{http.StatusBadRequest, http.StatusNotFound, http.StatusRequestEntityTooLarge},
+	},
+	"GET /api/room-types/:propertyId": {
+		Summary: "List the room types of a property",
+		Tag:     "rooms",
+		Data:    []RoomTypeResponse{},
+		Errors:  []int{http.StatusBadRequest},
+	},
+	"POST /api/stay-restrictions": {
+		Summary: "Restrict the stays that may be booked at a property, room or room type",
+		Tag:     "rooms",
+		Body:    createStayRestrictionRequest{},
+		Data:    StayRestrictionResponse{},
+		Errors:  []int{http.StatusBadRequest, http.StatusNotFound, http.StatusUnprocessableEntity},
+	},
+	"GET /api/stay-restrictions/:propertyId": {
+		Summary: "List the stay restrictions of a property",
+		Tag:     "rooms",
+		Data:    []StayRestrictionResponse{},
+		Errors:  []int{http.StatusBadRequest},
+	},
+	"DELETE /api/stay-restrictions/:restrictionId": {
+		Summary: "Delete a stay restriction",
+		Tag:     "rooms",
+		Errors:  []int{http.StatusBadRequest, http.StatusNotFound},
+	},
+	"POST /api/charges": {
+		Summary: "Add a tax or fee to the bookings of a property",
+		Tag:     "hotels",
+		Body:    createChargeRequest{},
+		Data:    ChargeResponse{},
+		Errors:  []int{http.StatusBadRequest, http.StatusNotFound},
+	},
+	"GET /api/charges/:propertyId": {
+		Summary: "List the taxes and fees of a property",
+		Tag:     "hotels",
+		Data:    []ChargeResponse{},
+		Errors:  []int{http.StatusBadRequest},
+	},
+	"DELETE /api/charges/:chargeId": {
+		Summary: "Delete a tax or fee; bookings keep the amounts they were charged",
+		Tag:     "hotels",
+		Errors:  []int{http.StatusBadRequest, http.StatusNotFound},
+	},
+	"POST /api/promotions": {
+		Summary: "Create a promotion code of an agent",
+		Tag:     "promotions",
+		Body:    createPromotionRequest{},
+		Data:    PromotionResponse{},
+		Errors:  []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity},
+	},
+	"GET /api/promotions/:agentId": {
+		Summary: "List the promotions of an agent",
+		Tag:     "promotions",
+		Data:    []PromotionResponse{},
+		Errors:  []int{http.StatusBadRequest},
+	},
+	"GET /api/rooms/:propertyId/availability": {
+		Summary: "List the rooms of a property free for a stay and large enough for a party",
+		Tag:     "rooms",
+		Query:   availabilityRequest{},
+		Data:    AvailabilityResponse{},
+		Errors:  []int{http.StatusBadRequest, http.StatusNotFound, http.StatusUnprocessableEntity},
+	},
+	"DELETE /api/rooms/:roomId": {
+		Summary: "Delete a room",
+		Tag:     "rooms",
+		Errors:  []int{http.StatusBadRequest, http.StatusNotFound},
+	},
+
+	"POST /api/banks/": {
+		Summary: "Create a bank account",
+		Tag:     "banks",
+		Form:    bankAccountRequest{},
+		Data:    db.T_Banks{},
+		Errors:  []int{http.StatusBadRequest, http.StatusRequestEntityTooLarge},
+	},
+	"PUT /api/banks/:bankId": {
+		Summary: "Update a bank account",
+		Tag:     "banks",
+		Form:    bankAccountRequest{},
+		Errors:  []int{http.StatusBadRequest, http.StatusNotFound, http.StatusRequestEntityTooLarge},
+	},
+	"GET /api/banks/:agentId": {
+		Summary: "List the bank accounts of an agent",
+		Tag:     "banks",
+		Data:    []BankResponse{},
+		Errors:  []int{http.StatusBadRequest},
+	},
+
+	"GET /api/staffs/:agentId": {
+		Summary: "List the staff of an agent",
+		Tag:     "staffs",
+		Data:    []StaffResponse{},
+		Errors:  []int{http.StatusBadRequest},
+	},
+	"POST /api/staffs": {
+		Summary: "Create a staff member of an agent",
+		Tag:     "staffs",
+		Form:    createStaffRequest{},
+		Data:    db.T_Users{},
+		Errors:  []int{http.StatusBadRequest, http.StatusRequestEntityTooLarge},
+	},
+}
+
+// buildOpenAPI renders the OpenAPI 3 document of routes.
+func buildOpenAPI(routes gin.RoutesInfo, serverURL string) ([]byte, error) {
+	builder := &schemaBuilder{components: map[string]any{}}
+	builder.components["Paging"] = builder.jsonSchema(reflect.TypeOf(Paging{}))
+	builder.components["ErrorResponse"] = map[string]any{
+		"type":     "object",
+		"required": []string{"error"},
+		"properties": map[string]any{
+			"error": builder.jsonSchema(reflect.TypeOf(ErrorBody{})),
+		},
+	}
+
+	paths := map[string]map[string]any{}
+	for _, route := range routes {
+		doc, ok := routeDocs[route.Method+" "+route.Path]
+		if !ok {
+			continue
+		}
+		path, params := openAPIPath(route.Path)
+		if paths[path] == nil {
+			paths[path] = map[string]any{}
+		}
+		paths[path][strings.ToLower(route.Method)] = builder.operation(doc, params)
+	}
+
+	return json.Marshal(map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   "BookingApp sub server",
+			"version": "1.0.0",
+		},
+		"servers":    []any{map[string]any{"url": serverURL}},
+		"paths":      paths,
+		"components": map[string]any{"schemas": builder.components},
+	})
+}
+
+// openAPIPath converts a gin path to an OpenAPI one, returning the path
+// parameters it declares. Named parameters are IDs; catch-all ones are
+// file paths.
+func openAPIPath(path string) (string, []any) {
+	segments := strings.Split(path, "/")
+	var params []any
+	for i, segment := range segments {
+		if segment == "" || (segment[0] != ':' && segment[0] != '*') {
+			continue
+		}
+		name := segment[1:]
+		schema := map[string]any{"type": "integer", "minimum": 1}
+		if segment[0] == '*' {
+			schema = map[string]any{"type": "string"}
+		}
+		params = append(params, map[string]any{
+			"name":     name,
+			"in":       "path",
+			"required": true,
+			"schema":   schema,
+		})
+		segments[i] = "{" + name + "}"
+	}
+	return strings.Join(segments, "/"), params
+}
+
+func (builder *schemaBuilder) operation(doc routeDoc, params []any) map[string]any {
+	operation := map[string]any{
+		"summary": doc.Summary,
+		"tags":    []string{doc.Tag},
+	}
+	if doc.Deprecated {
+		operation["deprecated"] = true
+	}
+
+	if doc.Query != nil {
+		params = append(params, builder.queryParameters(reflect.TypeOf(doc.Query))...)
+	}
+	if len(params) > 0 {
+		operation["parameters"] = params
+	}
+
+	switch {
+	case doc.Body != nil:
+		operation["requestBody"] = map[string]any{
+			"required": true,
+			"content": map[string]any{
+				"application/json": map[string]any{"schema": builder.jsonSchema(reflect.TypeOf(doc.Body))},
+			},
+		}
+	case doc.Form != nil:
+		operation["requestBody"] = map[string]any{
+			"required": true,
+			"content": map[string]any{
+				"multipart/form-data": map[string]any{"schema": builder.formSchema(reflect.TypeOf(doc.Form))},
+			},
+		}
+	}
+
+	responses := map[string]any{}
+	if doc.Raw != "" {
+		responses["200"] = map[string]any{
+			"description": "OK",
+			"content":     map[string]any{doc.Raw: map[string]any{}},
+		}
+	} else {
+		envelope := map[string]any{
+			"description": "OK",
+			"content":     map[string]any{"application/json": map[string]any{"schema": builder.envelope(doc)}},
+		}
+		responses["200"] = envelope
+		if doc.Unavailable {
+			unavailable := map[string]any{"description": http.StatusText(http.StatusServiceUnavailable)}
+			for key, value := range envelope {
+				if key != "description" {
+					unavailable[key] = value
+				}
+			}
+			responses["503"] = unavailable
+		}
+	}
+	for _, status := range append(doc.Errors, http.StatusInternalServerError) {
+		responses[strconv.Itoa(status)] = map[string]any{
+			"description": http.StatusText(status),
+			"content": map[string]any{
+				"application/json": map[string]any{"schema": schemaRef("ErrorResponse")},
+			},
+		}
+	}
+	operation["responses"] = responses
+	return operation
+}
+
+// envelope is the schema of the Response a route answers on success.
+func (builder *schemaBuilder) envelope(doc routeDoc) map[string]any {
+	properties := map[string]any{
+		"message": map[string]any{"type": "string"},
+	}
+	if doc.Data != nil {
+		properties["data"] = builder.jsonSchema(reflect.TypeOf(doc.Data))
+	}
+	if doc.Paged {
+		properties["paging"] = schemaRef("Paging")
+	}
+	return map[string]any{"type": "object", "properties": properties}
+}
+
+// schemaBuilder derives JSON schemas from Go types, collecting named
+// response and body types as components.
+type schemaBuilder struct {
+	components map[string]any
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	fileHeaderType = reflect.TypeOf(multipart.FileHeader{})
+)
+
+func schemaRef(name string) map[string]any {
+	return map[string]any{"$ref": "#/components/schemas/" + name}
+}
+
+// jsonSchema describes how t is encoded in JSON.
+func (builder *schemaBuilder) jsonSchema(t reflect.Type) map[string]any {
+	switch t {
+	case timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case dateType:
+		return map[string]any{"type": "string", "format": "date"}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		schema := builder.jsonSchema(t.Elem())
+		if _, ok := schema["$ref"]; ok {
+			return map[string]any{"allOf": []any{schema}, "nullable": true}
+		}
+		schema["nullable"] = true
+		return schema
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": builder.jsonSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": builder.jsonSchema(t.Elem())}
+	case reflect.Interface:
+		return map[string]any{}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return builder.structSchema(t, "json")
+		}
+		if _, ok := builder.components[t.Name()]; !ok {
+			// Reserve the name first so recursive types terminate
+			builder.components[t.Name()] = nil
+			builder.components[t.Name()] = builder.structSchema(t, "json")
+		}
+		return schemaRef(t.Name())
+	default:
+		return scalarSchema(t)
+	}
+}
+
+// formSchema describes a multipart form bound to t.
+func (builder *schemaBuilder) formSchema(t reflect.Type) map[string]any {
+	return builder.structSchema(t, "form")
+}
+
+// formFieldSchema describes one form field or query parameter of type t.
+func (builder *schemaBuilder) formFieldSchema(t reflect.Type) map[string]any {
+	switch {
+	case t == timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case t == dateType:
+		return map[string]any{"type": "string", "format": "date"}
+	case t == fileHeaderType:
+		return map[string]any{"type": "string", "format": "binary"}
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return builder.formFieldSchema(t.Elem())
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": builder.formFieldSchema(t.Elem())}
+	default:
+		return scalarSchema(t)
+	}
+}
+
+// structSchema describes the fields of t named by tag, which is "json" or
+// "form". With json, fields without omitempty are always present; with
+// form, fields with a required binding rule must be sent.
+func (builder *schemaBuilder) structSchema(t reflect.Type, tag string) map[string]any {
+	properties := map[string]any{}
+	var required []string
+	builder.eachField(t, tag, func(name string, field reflect.StructField, omitempty bool) {
+		var schema map[string]any
+		if tag == "json" {
+			schema = builder.jsonSchema(field.Type)
+		} else {
+			schema = builder.formFieldSchema(field.Type)
+		}
+		applyBindingRules(schema, field.Tag.Get("binding"))
+		properties[name] = schema
+
+		if tag == "json" && !omitempty || hasRule(field.Tag.Get("binding"), "required") {
+			required = append(required, name)
+		}
+	})
+
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		sort.Strings(required)
+		schema["required"] = required
+	}
+	return schema
+}
+
+func (builder *schemaBuilder) queryParameters(t reflect.Type) []any {
+	var params []any
+	builder.eachField(t, "form", func(name string, field reflect.StructField, _ bool) {
+		schema := builder.formFieldSchema(field.Type)
+		applyBindingRules(schema, field.Tag.Get("binding"))
+		params = append(params, map[string]any{
+			"name":     name,
+			"in":       "query",
+			"required": hasRule(field.Tag.Get("binding"), "required"),
+			"schema":   schema,
+		})
+	})
+	return params
+}
+
+// eachField calls fn for every exported field of t encoded under tag,
+// flattening embedded structs the way encoding/json and gin do.
+func (builder *schemaBuilder) eachField(t reflect.Type, tag string, fn func(name string, field reflect.StructField, omitempty bool)) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		options := strings.Split(field.Tag.Get(tag), ",")
+		name := options[0]
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			builder.eachField(field.Type, tag, fn)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		omitempty := false
+		for _, option := range options[1:] {
+			omitempty = omitempty || option == "omitempty"
+		}
+		fn(name, field, omitempty)
+	}
+}
+
+func scalarSchema(t reflect.Type) map[string]any {
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]any{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	default:
+		return map[string]any{}
+	}
+}
+
+// applyBindingRules documents the validator rules of a field that OpenAPI
+// can express.
+func applyBindingRules(schema map[string]any, rules string) {
+	for _, rule := range strings.Split(rules, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "dive":
+			// Later rules apply to the items
+			return
+		case "email":
+			schema["format"] = "email"
+		case "unique":
+			// unique=Field compares one field of the items only
+			if param == "" {
+				schema["uniqueItems"] = true
+			}
+		case "numeric":
+			schema["pattern"] = "^[0-9]+$"
+		case "latitude":
+			schema["minimum"], schema["maximum"] = -90, 90
+		case "longitude":
+			schema["minimum"], schema["maximum"] = -180, 180
+		case "oneof":
+			schema["enum"] = strings.Fields(param)
+		case "min", "max":
+			value, err := strconv.ParseFloat(param, 64)
+			if err != nil {
+				continue
+			}
+			key := map[string]string{"min": "minimum", "max": "maximum"}[name]
+			switch schema["type"] {
+			case "string":
+				key = map[string]string{"min": "minLength", "max": "maxLength"}[name]
+			case "array":
+				key = map[string]string{"min": "minItems", "max": "maxItems"}[name]
+			}
+			schema[key] = value
+		}
+	}
+}
+
+func hasRule(rules, rule string) bool {
+	for _, r := range strings.Split(rules, ",") {
+		if r == rule {
+			return true
+		}
+	}
+	return false
+}
+
+// docsPage renders the document served at /openapi.json with Redoc.
+const docsPage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>BookingApp sub server API</title>
+<meta name="viewport" content="width=device-width, initial-scale=1">
+</head>
+<body>
+<redoc spec-url="/openapi.json"></redoc>
+<script src="https://cdn.redoc.ly/redoc/v2.1.5/bundles/redoc.standalone.js"></script>
+</body>
+</html>
+`
+
+func (server *Server) openAPI(ctx *gin.Context) {
+	ctx.Data(http.StatusOK, "application/json; charset=utf-8", server.openAPIDocument)
+}
+
+// docs serves the documentation UI. It loosens the default Content Security
+// Policy just enough for Redoc to load and render.
+func (server *Server) docs(ctx *gin.Context) {
+	ctx.Header("Content-Security-Policy", "default-src 'none'; "+
+		"script-src https://cdn.redoc.ly; "+
+		"style-src 'unsafe-inline' https://fonts.googleapis.com; "+
+		"font-src https://fonts.gstatic.com; "+
+		"img-src data: https://cdn.redoc.ly; "+
+		"connect-src 'self'; "+
+		"worker-src blob:; "+
+		"frame-ancestors 'none'")
+	ctx.Data(http.StatusOK, "text/html; charset=utf-8", []byte(docsPage))
+}
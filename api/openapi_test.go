@@ -0,0 +1,44 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestRouteDocs keeps routeDocs in step with the router: every route must
+// be documented, and every documented route must exist.
+func TestRouteDocs(t *testing.T) {
+	server, _ := newTestServer(t)
+
+	routed := map[string]bool{}
+	for _, route := range server.router.Routes() {
+		key := route.Method + " " + route.Path
+		routed[key] = true
+		if _, ok := routeDocs[key]; !ok {
+			t.Errorf("route %s is missing from routeDocs", key)
+		}
+	}
+	for key := range routeDocs {
+		if !routed[key] {
+			t.Errorf("routeDocs documents %s, which is not routed", key)
+		}
+	}
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	server, _ := newTestServer(t)
+
+	var document struct {
+		OpenAPI string                    `json:"openapi"`
+		Paths   map[string]map[string]any `json:"paths"`
+	}
+	if err := json.Unmarshal(server.openAPIDocument, &document); err != nil {
+		t.Fatalf("cannot decode the OpenAPI document: %v", err)
+	}
+	if document.OpenAPI == "" {
+		t.Fatal("the OpenAPI document has no version")
+	}
+	if _, ok := document.Paths["/api/bookings/{bookingId}"]["patch"]; !ok {
+		t.Fatalf("the OpenAPI document lacks PATCH /api/bookings/{bookingId}: %v", document.Paths)
+	}
+}
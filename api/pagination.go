@@ -0,0 +1,152 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lancer2672/BookingAppSubServer/db"
+)
+
+// defaultPageLimit is used when a list request does not set limit. The
+// largest accepted limit is enforced by the binding rules of each request.
+const defaultPageLimit = 20
+
+// Paging describes where a page sits in a cursor-paginated list.
+type Paging struct {
+	Limit      int    `json:"limit"`
+	NextCursor string `json:"nextCursor,omitempty"`
+	HasMore    bool   `json:"hasMore"`
+}
+
+// bookingStatuses lists the values accepted by the status filter, in the
+// form of a oneof rule parameter.
+const bookingStatuses = "PENDING CONFIRMED CHECKIN CHECKOUT CANCELED"
+
+type listBookingsRequest struct {
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=100"`
+	Cursor string `form:"cursor"`
+	// Comma-separated booking statuses, any of which matches
+	Status     string `form:"status"`
+	PropertyId uint   `form:"propertyId"`
+	DateField  string `form:"dateField" binding:"omitempty,oneof=stay created"`
+	// Dates in the time zone of the filtered property, or of
+	// PROPERTY_TIMEZONE when no property is given; both are included
+	From   Date   `form:"from" binding:"omitempty,date"`
+	To     Date   `form:"to" binding:"omitempty,date"`
+	SortBy string `form:"sortBy" binding:"omitempty,oneof=createdAt startDate endDate totalPrice"`
+	Order  string `form:"order" binding:"omitempty,oneof=asc desc"`
+}
+
+// bookingSortColumns maps the sortBy query values to t_bookings columns.
+var bookingSortColumns = map[string]string{
+	"createdAt":  db.BookingSortCreatedAt,
+	"startDate":  db.BookingSortStartDate,
+	"endDate":    db.BookingSortEndDate,
+	"totalPrice": db.BookingSortTotalPrice,
+}
+
+// bookingCursor points just past the last booking of a page. It carries the
+// sort key it was produced for so it cannot be replayed with another order.
+type bookingCursor struct {
+	SortBy string    `json:"s"`
+	Order  string    `json:"o"`
+	Time   time.Time `json:"t"`
+	Price  float64   `json:"p"`
+	Id     uint      `json:"i"`
+}
+
+func (c bookingCursor) encode() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeBookingCursor(value string) (bookingCursor, error) {
+	var cursor bookingCursor
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return cursor, errors.New("invalid cursor")
+	}
+	if err := json.Unmarshal(raw, &cursor); err != nil {
+		return cursor, errors.New("invalid cursor")
+	}
+	return cursor, nil
+}
+
+// toParams applies defaults to req and converts it to store list
+// parameters, reading the date filters in location. The limit is left to
+// the caller.
+func (req *listBookingsRequest) toParams(location *time.Location) (db.BookingListParams, error) {
+	if req.Limit == 0 {
+		req.Limit = defaultPageLimit
+	}
+	if req.DateField == "" {
+		req.DateField = "stay"
+	}
+	if req.SortBy == "" {
+		req.SortBy = "createdAt"
+	}
+	if req.Order == "" {
+		req.Order = "desc"
+	}
+
+	params := db.BookingListParams{
+		PropertyId: req.PropertyId,
+		ByCreated:  req.DateField == "created",
+		SortBy:     bookingSortColumns[req.SortBy],
+		Descending: req.Order == "desc",
+	}
+
+	if req.Status != "" {
+		for _, status := range strings.Split(req.Status, ",") {
+			status = strings.ToUpper(strings.TrimSpace(status))
+			if !slices.Contains(strings.Fields(bookingStatuses), status) {
+				return params, FieldError{Field: "status", Rule: "oneof", param: bookingStatuses}
+			}
+			params.Statuses = append(params.Statuses, status)
+		}
+	}
+
+	if !req.From.IsZero() {
+		params.From = timeOfDay{}.on(req.From, location)
+	}
+	if !req.To.IsZero() {
+		if !req.From.IsZero() && req.To.Before(req.From.Time) {
+			return params, FieldError{Field: "to", Rule: "gtefield", param: "from"}
+		}
+		// The last day is covered up to its end
+		params.To = timeOfDay{}.on(dateOf(req.To.AddDate(0, 0, 1)), location)
+	}
+
+	if req.Cursor != "" {
+		cursor, err := decodeBookingCursor(req.Cursor)
+		if err != nil {
+			return params, FieldError{Field: "cursor", Rule: "cursor"}
+		}
+		if cursor.SortBy != req.SortBy || cursor.Order != req.Order {
+			return params, FieldError{Field: "cursor", Rule: "cursormismatch"}
+		}
+		params.After = &db.BookingCursor{Time: cursor.Time, Price: cursor.Price, Id: cursor.Id}
+	}
+
+	return params, nil
+}
+
+// nextBookingCursor builds the cursor that continues after last.
+func nextBookingCursor(req listBookingsRequest, last db.T_Bookings) string {
+	cursor := bookingCursor{SortBy: req.SortBy, Order: req.Order, Id: last.Id}
+	switch req.SortBy {
+	case "startDate":
+		cursor.Time = last.Start_Date
+	case "endDate":
+		cursor.Time = last.End_Date
+	case "totalPrice":
+		cursor.Price = last.Total_Price
+	default:
+		cursor.Time = last.Create_At
+	}
+	return cursor.encode()
+}
@@ -0,0 +1,328 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lancer2672/BookingAppSubServer/db"
+	"github.com/lancer2672/BookingAppSubServer/internal/utils"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type createPromotionRequest struct {
+	// TODO: Retrieve from token
+	AgentId uint `json:"agentId" binding:"required"`
+	// Codes are matched without regard to case
+	Code string `json:"code" binding:"required,min=3,max=32,alphanum"`
+	Name string `json:"name" binding:"max=100"`
+	Type string `json:"type" binding:"required,oneof=PERCENTAGE FIXED"`
+	// Percent off the price of the eligible rooms, or amount off it
+	Value float64 `json:"value" binding:"required,gt=0"`
+	// Local arrival dates the promotion is valid for, both included
+	StartDate      Date `json:"startDate" binding:"required,date"`
+	EndDate        Date `json:"endDate" binding:"required,date"`
+	MinNights      int  `json:"minNights" binding:"min=0,max=30"`
+	MaxRedemptions int  `json:"maxRedemptions" binding:"min=0"`
+	// Restrict the promotion to these properties, rooms and room types of
+	// the agent; it applies to every property of the agent when all are
+	// empty
+	PropertyIds []uint `json:"propertyIds" binding:"max=20,unique,dive,required"`
+	RoomIds     []uint `json:"roomIds" binding:"max=20,unique,dive,required"`
+	RoomTypeIds []uint `json:"roomTypeIds" binding:"max=20,unique,dive,required"`
+}
+
+type PromotionTargetInfo struct {
+	PropertyId uint  `json:"propertyId"`
+	RoomId     *uint `json:"roomId"`
+	RoomTypeId *uint `json:"roomTypeId"`
+}
+
+type PromotionResponse struct {
+	Id             uint                  `json:"id"`
+	AgentId        uint                  `json:"agentId"`
+	Code           string                `json:"code"`
+	Name           string                `json:"name"`
+	Type           string                `json:"type"`
+	Value          float64               `json:"value"`
+	StartDate      Date                  `json:"startDate"`
+	EndDate        Date                  `json:"endDate"`
+	MinNights      int                   `json:"minNights"`
+	MaxRedemptions int                   `json:"maxRedemptions"`
+	Redemptions    int                   `json:"redemptions"`
+	Targets        []PromotionTargetInfo `json:"targets"`
+}
+
+func newPromotionResponse(promotion db.T_Promotions, targets []db.T_Promotion_Targets) PromotionResponse {
+	response := PromotionResponse{
+		Id:             promotion.Id,
+		AgentId:        promotion.Fk_Agent_Id,
+		Code:           promotion.Code,
+		Name:           promotion.Name,
+		Type:           promotion.Type,
+		Value:          promotion.Value,
+		StartDate:      dateOf(promotion.Start_Date),
+		EndDate:        dateOf(promotion.End_Date),
+		MinNights:      promotion.Min_Nights,
+		MaxRedemptions: promotion.Max_Redemptions,
+		Redemptions:    promotion.Redemptions,
+		Targets:        []PromotionTargetInfo{},
+	}
+	for _, target := range targets {
+		if target.Fk_Promotion_Id == promotion.Id {
+			response.Targets = append(response.Targets, PromotionTargetInfo{
+				PropertyId: target.Fk_Property_Id,
+				RoomId:     target.Fk_Room_Id,
+				RoomTypeId: target.Fk_Room_Type_Id,
+			})
+		}
+	}
+	return response
+}
+
+// promotionCode is the form codes are stored and looked up in.
+func promotionCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func (server *Server) createPromotion(ctx *gin.Context) {
+	var req createPromotionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondInvalid(ctx, err)
+		return
+	}
+	annotateSpan(ctx, attribute.Int64("agent.id", int64(req.AgentId)))
+
+	promotion := db.T_Promotions{
+		Fk_Agent_Id:     req.AgentId,
+		Code:            promotionCode(req.Code),
+		Name:            req.Name,
+		Type:            req.Type,
+		Value:           req.Value,
+		Start_Date:      req.StartDate.Time,
+		End_Date:        req.EndDate.Time,
+		Min_Nights:      req.MinNights,
+		Max_Redemptions: req.MaxRedemptions,
+		Create_At:       time.Now().UTC(),
+	}
+	var targets []db.T_Promotion_Targets
+	err := server.store.ExecTx(ctx, func(store db.Store) error {
+		// The unique index still rejects a code taken concurrently
+		_, err := store.GetPromotionByCode(ctx, promotion.Code)
+		if err == nil {
+			return errPromotionCodeTaken
+		}
+		if !errors.Is(err, db.ErrNotFound) {
+			return err
+		}
+
+		agentProperty := func(propertyId uint) error {
+			property, err := store.GetProperty(ctx, propertyId)
+			if errors.Is(err, db.ErrNotFound) {
+				return errHotelNotFound
+			}
+			if err != nil {
+				return err
+			}
+			if property.Fk_Argent_Id != req.AgentId {
+				return errHotelNotOfAgent
+			}
+			return nil
+		}
+		for _, propertyId := range req.PropertyIds {
+			if err := agentProperty(propertyId); err != nil {
+				return err
+			}
+			targets = append(targets, db.T_Promotion_Targets{Fk_Property_Id: propertyId})
+		}
+		for _, roomId := range req.RoomIds {
+			room, err := store.GetRoom(ctx, roomId)
+			if errors.Is(err, db.ErrNotFound) {
+				return errRoomNotFound
+			}
+			if err != nil {
+				return err
+			}
+			if err := agentProperty(room.Fk_Property_Id); err != nil {
+				return err
+			}
+			targets = append(targets, db.T_Promotion_Targets{Fk_Property_Id: room.Fk_Property_Id, Fk_Room_Id: &room.Id})
+		}
+		for _, roomTypeId := range req.RoomTypeIds {
+			roomType, err := store.GetRoomType(ctx, roomTypeId)
+			if errors.Is(err, db.ErrNotFound) {
+				return errRoomTypeNotFound
+			}
+			if err != nil {
+				return err
+			}
+			if err := agentProperty(roomType.Fk_Property_Id); err != nil {
+				return err
+			}
+			targets = append(targets, db.T_Promotion_Targets{Fk_Property_Id: roomType.Fk_Property_Id, Fk_Room_Type_Id: &roomType.Id})
+		}
+
+		if err := store.CreatePromotion(ctx, &promotion); err != nil {
+			return err
+		}
+		for i := range targets {
+			targets[i].Fk_Promotion_Id = promotion.Id
+		}
+		return store.AddPromotionTargets(ctx, targets)
+	})
+	if err != nil {
+		respondErr(ctx, err)
+		return
+	}
+
+	respondOK(ctx, newPromotionResponse(promotion, targets))
+}
+
+func (server *Server) getPromotionsByAgent(ctx *gin.Context) {
+	agentId, err := strconv.ParseUint(ctx.Param("agentId"), 10, 64)
+	if err != nil {
+		respondInvalid(ctx, FieldError{Field: "agentId", Rule: "id"})
+		return
+	}
+
+	promotions, err := server.store.ListPromotionsByAgent(ctx, uint(agentId))
+	if err != nil {
+		respondInternalError(ctx, err)
+		return
+	}
+	promotionIds := make([]uint, 0, len(promotions))
+	for _, promotion := range promotions {
+		promotionIds = append(promotionIds, promotion.Id)
+	}
+	targets, err := server.store.ListPromotionTargets(ctx, promotionIds)
+	if err != nil {
+		respondInternalError(ctx, err)
+		return
+	}
+
+	responses := []PromotionResponse{}
+	for _, promotion := range promotions {
+		responses = append(responses, newPromotionResponse(promotion, targets))
+	}
+	respondOK(ctx, responses)
+}
+
+// priceLine is the price of one room, or of the rooms of one room type, for
+// the whole stay. Exactly one of roomId and roomTypeId is set.
+type priceLine struct {
+	roomId     uint
+	roomTypeId uint
+	amount     float64
+}
+
+func subtotal(lines []priceLine) float64 {
+	var total float64
+	for _, line := range lines {
+		total += line.amount
+	}
+	return total
+}
+
+// repriced is the price, once its stay changes to nights nights, of a room
+// or of the rooms of a room type booked at amount for bookedNights nights.
+// The keptNights nights still booked keep their booked price and the other
+// nights cost nightPrice each.
+func repriced(amount float64, bookedNights, keptNights, nights int, nightPrice float64) float64 {
+	if keptNights == 0 {
+		return nightPrice * float64(nights)
+	}
+	return roundPrice(amount*float64(keptNights)/float64(bookedNights) + nightPrice*float64(nights-keptNights))
+}
+
+// promotionRules is a promotion with its targets.
+type promotionRules struct {
+	promotion db.T_Promotions
+	targets   []db.T_Promotion_Targets
+}
+
+// loadPromotion reads the targets of promotion.
+func loadPromotion(ctx context.Context, store db.Store, promotion db.T_Promotions) (promotionRules, error) {
+	targets, err := store.ListPromotionTargets(ctx, []uint{promotion.Id})
+	if err != nil {
+		return promotionRules{}, err
+	}
+	return promotionRules{promotion: promotion, targets: targets}, nil
+}
+
+// changeRedemptions gives back the redemption of the promotion of a booking
+// being canceled, or takes it again for a booking being reopened as long as
+// the promotion is not fully redeemed by then.
+func changeRedemptions(ctx context.Context, store db.Store, promotionId uint, canceled bool) error {
+	if canceled {
+		return store.ReleasePromotion(ctx, promotionId)
+	}
+	promotion, err := store.LockPromotion(ctx, promotionId)
+	if err != nil {
+		return err
+	}
+	if promotion.Max_Redemptions != 0 && promotion.Redemptions >= promotion.Max_Redemptions {
+		return errPromotionRedeemed
+	}
+	return store.RedeemPromotion(ctx, promotion.Id)
+}
+
+// eligible reports whether the promotion applies to line at the property.
+func (rules promotionRules) eligible(propertyId uint, line priceLine) bool {
+	if len(rules.targets) == 0 {
+		return true
+	}
+	for _, target := range rules.targets {
+		if target.Fk_Property_Id != propertyId {
+			continue
+		}
+		switch {
+		case target.Fk_Room_Id != nil:
+			if line.roomId != 0 && *target.Fk_Room_Id == line.roomId {
+				return true
+			}
+		case target.Fk_Room_Type_Id != nil:
+			if line.roomTypeId != 0 && *target.Fk_Room_Type_Id == line.roomTypeId {
+				return true
+			}
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+// discount returns the amount the promotion takes off a stay at property
+// arriving on arrival, or why it does not apply. It does not look at
+// redemptions, which only count when a booking is made.
+func (rules promotionRules) discount(property db.T_Properties, arrival Date, nights int, lines []priceLine) (float64, error) {
+	promotion := rules.promotion
+	if promotion.Fk_Agent_Id != property.Fk_Argent_Id {
+		return 0, unprocessableError(CodePromotionNotApplicable, "Promotion code is not valid at this hotel")
+	}
+	from, to := dateOf(promotion.Start_Date), dateOf(promotion.End_Date)
+	if arrival.Before(from.Time) || arrival.After(to.Time) {
+		return 0, unprocessableError(CodePromotionNotApplicable, fmt.Sprintf("Promotion code is valid for arrivals from %s to %s", from, to))
+	}
+	if nights < promotion.Min_Nights {
+		return 0, unprocessableError(CodePromotionNotApplicable, fmt.Sprintf("Promotion code needs a stay of at least %d nights", promotion.Min_Nights))
+	}
+
+	var eligible float64
+	for _, line := range lines {
+		if rules.eligible(property.Id, line) {
+			eligible += line.amount
+		}
+	}
+	if eligible == 0 {
+		return 0, unprocessableError(CodePromotionNotApplicable, "Promotion code does not apply to the booked rooms")
+	}
+	if promotion.Type == utils.PromotionType_Percentage {
+		return math.Round(eligible*promotion.Value) / 100, nil
+	}
+	return min(promotion.Value, eligible), nil
+}
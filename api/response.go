@@ -0,0 +1,189 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/lancer2672/BookingAppSubServer/internal/tracing"
+)
+
+// Error codes returned in ErrorBody.Code. They are part of the API contract,
+// clients match on them to show localized messages.
+const (
+	CodeValidationFailed        = "VALIDATION_FAILED"
+	CodeNotFound                = "NOT_FOUND"
+	CodeBookingNotFound         = "BOOKING_NOT_FOUND"
+	CodeRoomNotFound            = "ROOM_NOT_FOUND"
+	CodeHotelNotFound           = "HOTEL_NOT_FOUND"
+	CodeBankAccountNotFound     = "BANK_ACCOUNT_NOT_FOUND"
+	CodeRoomAlreadyBooked       = "ROOM_ALREADY_BOOKED"
+	CodeRoomNotAvailable        = "ROOM_NOT_AVAILABLE"
+	CodeHotelNotAvailable       = "HOTEL_NOT_AVAILABLE"
+	CodeRoomNotInHotel          = "ROOM_NOT_IN_HOTEL"
+	CodeRoomNotInBooking        = "ROOM_NOT_IN_BOOKING"
+	CodeRoomOverOccupied        = "ROOM_OVER_OCCUPIED"
+	CodeRoomTypeNotFound        = "ROOM_TYPE_NOT_FOUND"
+	CodeRoomTypeSoldOut         = "ROOM_TYPE_SOLD_OUT"
+	CodeRoomBookedByType        = "ROOM_BOOKED_BY_TYPE"
+	CodeUnitNotNeeded           = "UNIT_NOT_NEEDED"
+	CodeNoFreeUnit              = "NO_FREE_UNIT"
+	CodeStayRestrictionNotFound = "STAY_RESTRICTION_NOT_FOUND"
+	CodeClosedToArrival         = "CLOSED_TO_ARRIVAL"
+	CodeClosedToDeparture       = "CLOSED_TO_DEPARTURE"
+	CodeStayTooShort            = "STAY_TOO_SHORT"
+	CodeStayTooLong             = "STAY_TOO_LONG"
+	CodePromotionNotFound       = "PROMOTION_NOT_FOUND"
+	CodePromotionCodeTaken      = "PROMOTION_CODE_TAKEN"
+	CodePromotionRedeemed       = "PROMOTION_REDEEMED"
+	CodePromotionNotApplicable  = "PROMOTION_NOT_APPLICABLE"
+	CodeHotelNotOfAgent         = "HOTEL_NOT_OF_AGENT"
+	CodeChargeNotFound          = "CHARGE_NOT_FOUND"
+	CodeBookingNotModifiable    = "BOOKING_NOT_MODIFIABLE"
+	CodeEarlyCheckInNotOffered  = "EARLY_CHECK_IN_NOT_OFFERED"
+	CodeLateCheckOutNotOffered  = "LATE_CHECK_OUT_NOT_OFFERED"
+	CodeCheckInNotAllowed       = "CHECK_IN_NOT_ALLOWED"
+	CodeFileTooLarge            = "FILE_TOO_LARGE"
+	CodeInternal                = "INTERNAL_ERROR"
+)
+
+// Response is the envelope of every API response. Successful responses set
+// Data, and Paging for lists; failed ones only set Error.
+type Response struct {
+	Data    any        `json:"data,omitempty"`
+	Message string     `json:"message,omitempty"`
+	Paging  *Paging    `json:"paging,omitempty"`
+	Error   *ErrorBody `json:"error,omitempty"`
+}
+
+type ErrorBody struct {
+	Code    string       `json:"code"`
+	Message string       `json:"message"`
+	Details []FieldError `json:"details,omitempty"`
+	// TraceID identifies the request in traces and logs, to quote in bug
+	// reports
+	TraceID string `json:"traceId,omitempty"`
+}
+
+// FieldError describes one invalid request field. It doubles as an error so
+// request parsing helpers can return it directly; when they leave Message
+// empty, respondInvalid localizes the message of Rule.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule,omitempty"`
+	Message string `json:"message"`
+
+	param string
+}
+
+func (e FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+func respondOK(ctx *gin.Context, data any) {
+	ctx.JSON(http.StatusOK, Response{Data: data})
+}
+
+func respondMessage(ctx *gin.Context, message string, data any) {
+	ctx.JSON(http.StatusOK, Response{Data: data, Message: message})
+}
+
+func respondPage(ctx *gin.Context, data any, paging Paging) {
+	ctx.JSON(http.StatusOK, Response{Data: data, Paging: &paging})
+}
+
+func respondError(ctx *gin.Context, status int, code, message string) {
+	abortWithError(ctx, status, ErrorBody{Code: code, Message: message})
+}
+
+func abortWithError(ctx *gin.Context, status int, body ErrorBody) {
+	body.TraceID = tracing.TraceID(ctx.Request.Context())
+	ctx.AbortWithStatusJSON(status, Response{Error: &body})
+}
+
+// respondInternalError answers 500 without exposing err, which is logged
+// with the request by requestLogger.
+func respondInternalError(ctx *gin.Context, err error) {
+	ctx.Error(err)
+	respondError(ctx, http.StatusInternalServerError, CodeInternal, "Internal server error")
+}
+
+// respondInvalid answers 400 VALIDATION_FAILED for a binding or parsing
+// error, listing the offending fields when they are known. Messages are in
+// the language asked for by Accept-Language.
+func respondInvalid(ctx *gin.Context, err error) {
+	lang := requestLanguage(ctx)
+	ctx.Header("Content-Language", lang)
+	ctx.Writer.Header().Add("Vary", "Accept-Language")
+	body := ErrorBody{Code: CodeValidationFailed, Message: localize(lang, "request.invalid", "")}
+
+	var fieldErr FieldError
+	var validationErrs validator.ValidationErrors
+	var typeErr *json.UnmarshalTypeError
+	var syntaxErr *json.SyntaxError
+	switch {
+	case errors.As(err, &fieldErr):
+		if fieldErr.Message == "" {
+			fieldErr.Message = localize(lang, fieldErr.Rule, fieldErr.param)
+		}
+		body.Details = []FieldError{fieldErr}
+	case errors.As(err, &validationErrs):
+		for _, fe := range validationErrs {
+			param := fe.Param()
+			if strings.HasSuffix(fe.Tag(), "field") || strings.HasPrefix(fe.Tag(), "required_with") || strings.HasPrefix(fe.Tag(), "excluded_with") {
+				// Cross-field rules name the other field by its Go name
+				param = strings.ToLower(param[:1]) + param[1:]
+			}
+			body.Details = append(body.Details, FieldError{
+				Field:   fieldPath(fe),
+				Rule:    fe.Tag(),
+				Message: localize(lang, fe.Tag(), param),
+			})
+		}
+	case errors.As(err, &typeErr) && typeErr.Type == dateType:
+		body.Details = []FieldError{{
+			Field:   typeErr.Field,
+			Rule:    "date",
+			Message: localize(lang, "date", ""),
+		}}
+	case errors.As(err, &typeErr):
+		body.Details = []FieldError{{
+			Field:   typeErr.Field,
+			Rule:    "type",
+			Message: localize(lang, "type", jsonKind(typeErr.Type)),
+		}}
+	case errors.As(err, &syntaxErr):
+		body.Message = localize(lang, "request.json", "")
+	default:
+		body.Message = localize(lang, "request.malformed", "")
+	}
+
+	abortWithError(ctx, http.StatusBadRequest, body)
+}
+
+// fieldPath is the path of an invalid field relative to the request, such
+// as roomIds[1], without the name of the request type.
+func fieldPath(fe validator.FieldError) string {
+	_, path, _ := strings.Cut(fe.Namespace(), ".")
+	return path
+}
+
+// jsonKind names the JSON type a Go type is decoded from.
+func jsonKind(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	default:
+		return "number"
+	}
+}
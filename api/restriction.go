@@ -0,0 +1,231 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lancer2672/BookingAppSubServer/db"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// weekdayNames are the days of the week accepted by stay restrictions,
+// indexed by time.Weekday.
+var weekdayNames = []string{"SUN", "MON", "TUE", "WED", "THU", "FRI", "SAT"}
+
+type createStayRestrictionRequest struct {
+	PropertyId uint `json:"propertyId" binding:"required"`
+	// Restricts one room or room type instead of the whole property
+	RoomId     uint `json:"roomId"`
+	RoomTypeId uint `json:"roomTypeId" binding:"excluded_with=RoomId"`
+	// Local dates the rule covers, both included
+	StartDate Date `json:"startDate" binding:"required,date"`
+	EndDate   Date `json:"endDate" binding:"required,date"`
+	// Days of the week the rule covers, every day when empty
+	Weekdays          []string `json:"weekdays" binding:"max=7,unique,dive,oneof=SUN MON TUE WED THU FRI SAT"`
+	MinNights         int      `json:"minNights" binding:"min=0,max=30"`
+	MaxNights         int      `json:"maxNights" binding:"min=0,max=30"`
+	ClosedToArrival   bool     `json:"closedToArrival"`
+	ClosedToDeparture bool     `json:"closedToDeparture"`
+}
+
+type StayRestrictionResponse struct {
+	Id                uint     `json:"id"`
+	PropertyId        uint     `json:"propertyId"`
+	RoomId            *uint    `json:"roomId"`
+	RoomTypeId        *uint    `json:"roomTypeId"`
+	StartDate         Date     `json:"startDate"`
+	EndDate           Date     `json:"endDate"`
+	Weekdays          []string `json:"weekdays"`
+	MinNights         int      `json:"minNights"`
+	MaxNights         int      `json:"maxNights"`
+	ClosedToArrival   bool     `json:"closedToArrival"`
+	ClosedToDeparture bool     `json:"closedToDeparture"`
+}
+
+func newStayRestrictionResponse(restriction db.T_Stay_Restrictions) StayRestrictionResponse {
+	weekdays := []string{}
+	for day, name := range weekdayNames {
+		if restriction.Weekdays&(1<<day) != 0 {
+			weekdays = append(weekdays, name)
+		}
+	}
+	return StayRestrictionResponse{
+		Id:                restriction.Id,
+		PropertyId:        restriction.Fk_Property_Id,
+		RoomId:            restriction.Fk_Room_Id,
+		RoomTypeId:        restriction.Fk_Room_Type_Id,
+		StartDate:         dateOf(restriction.Start_Date),
+		EndDate:           dateOf(restriction.End_Date),
+		Weekdays:          weekdays,
+		MinNights:         restriction.Min_Nights,
+		MaxNights:         restriction.Max_Nights,
+		ClosedToArrival:   restriction.Closed_To_Arrival,
+		ClosedToDeparture: restriction.Closed_To_Departure,
+	}
+}
+
+func (server *Server) createStayRestriction(ctx *gin.Context) {
+	var req createStayRestrictionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondInvalid(ctx, err)
+		return
+	}
+	annotateSpan(ctx, attribute.Int64("property.id", int64(req.PropertyId)))
+
+	restriction := db.T_Stay_Restrictions{
+		Fk_Property_Id:      req.PropertyId,
+		Start_Date:          req.StartDate.Time,
+		End_Date:            req.EndDate.Time,
+		Min_Nights:          req.MinNights,
+		Max_Nights:          req.MaxNights,
+		Closed_To_Arrival:   req.ClosedToArrival,
+		Closed_To_Departure: req.ClosedToDeparture,
+	}
+	for _, name := range req.Weekdays {
+		restriction.Weekdays |= 1 << slices.Index(weekdayNames, name)
+	}
+
+	err := server.store.ExecTx(ctx, func(store db.Store) error {
+		if _, err := store.GetProperty(ctx, req.PropertyId); err != nil {
+			if errors.Is(err, db.ErrNotFound) {
+				return errHotelNotFound
+			}
+			return err
+		}
+		if req.RoomId != 0 {
+			room, err := store.GetRoom(ctx, req.RoomId)
+			if errors.Is(err, db.ErrNotFound) {
+				return errRoomNotFound
+			}
+			if err != nil {
+				return err
+			}
+			if room.Fk_Property_Id != req.PropertyId {
+				return errRoomNotInHotel
+			}
+			restriction.Fk_Room_Id = &room.Id
+		}
+		if req.RoomTypeId != 0 {
+			roomType, err := store.GetRoomType(ctx, req.RoomTypeId)
+			if errors.Is(err, db.ErrNotFound) {
+				return errRoomTypeNotFound
+			}
+			if err != nil {
+				return err
+			}
+			if roomType.Fk_Property_Id != req.PropertyId {
+				return errRoomNotInHotel
+			}
+			restriction.Fk_Room_Type_Id = &roomType.Id
+		}
+		return store.CreateStayRestriction(ctx, &restriction)
+	})
+	if err != nil {
+		respondErr(ctx, err)
+		return
+	}
+
+	respondOK(ctx, newStayRestrictionResponse(restriction))
+}
+
+func (server *Server) getStayRestrictions(ctx *gin.Context) {
+	propertyId, err := strconv.ParseUint(ctx.Param("propertyId"), 10, 64)
+	if err != nil {
+		respondInvalid(ctx, FieldError{Field: "propertyId", Rule: "id"})
+		return
+	}
+	annotateSpan(ctx, attribute.Int64("property.id", int64(propertyId)))
+
+	restrictions, err := server.store.ListStayRestrictions(ctx, uint(propertyId))
+	if err != nil {
+		respondInternalError(ctx, err)
+		return
+	}
+	responses := []StayRestrictionResponse{}
+	for _, restriction := range restrictions {
+		responses = append(responses, newStayRestrictionResponse(restriction))
+	}
+	respondOK(ctx, responses)
+}
+
+func (server *Server) deleteStayRestriction(ctx *gin.Context) {
+	restrictionId, err := strconv.ParseUint(ctx.Param("restrictionId"), 10, 64)
+	if err != nil {
+		respondInvalid(ctx, FieldError{Field: "restrictionId", Rule: "id"})
+		return
+	}
+
+	if err := server.store.DeleteStayRestriction(ctx, uint(restrictionId)); err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			err = errStayRestrictionNotFound
+		}
+		respondErr(ctx, err)
+		return
+	}
+
+	respondMessage(ctx, "Stay restriction deleted successfully", nil)
+}
+
+// stayRules are the restrictions that apply to one room or room type.
+type stayRules []db.T_Stay_Restrictions
+
+// rulesFor picks from the restrictions of a property those of the whole
+// property and those of roomId or roomTypeId. Pass 0 for neither.
+func rulesFor(restrictions []db.T_Stay_Restrictions, roomId, roomTypeId uint) stayRules {
+	var rules stayRules
+	for _, restriction := range restrictions {
+		switch {
+		case restriction.Fk_Room_Id != nil:
+			if *restriction.Fk_Room_Id != roomId {
+				continue
+			}
+		case restriction.Fk_Room_Type_Id != nil:
+			if *restriction.Fk_Room_Type_Id != roomTypeId {
+				continue
+			}
+		}
+		rules = append(rules, restriction)
+	}
+	return rules
+}
+
+// covers reports whether restriction applies on date.
+func covers(restriction db.T_Stay_Restrictions, date Date) bool {
+	if date.Before(dateOf(restriction.Start_Date).Time) || date.After(dateOf(restriction.End_Date).Time) {
+		return false
+	}
+	return restriction.Weekdays == 0 || restriction.Weekdays&(1<<date.Weekday()) != 0
+}
+
+// check returns why a stay from arrival to departure breaks the rules, or
+// nil when it does not. When several rules cover the arrival date the
+// longest minimum and the shortest maximum win.
+func (rules stayRules) check(arrival, departure Date) error {
+	minNights, maxNights := 0, 0
+	for _, rule := range rules {
+		if covers(rule, arrival) {
+			if rule.Closed_To_Arrival {
+				return unprocessableError(CodeClosedToArrival, fmt.Sprintf("Arrivals are closed on %s", arrival))
+			}
+			minNights = max(minNights, rule.Min_Nights)
+			if rule.Max_Nights != 0 && (maxNights == 0 || rule.Max_Nights < maxNights) {
+				maxNights = rule.Max_Nights
+			}
+		}
+		if rule.Closed_To_Departure && covers(rule, departure) {
+			return unprocessableError(CodeClosedToDeparture, fmt.Sprintf("Departures are closed on %s", departure))
+		}
+	}
+
+	nights := stayNights(arrival, departure)
+	if nights < minNights {
+		return unprocessableError(CodeStayTooShort, fmt.Sprintf("Stays arriving on %s must be at least %d nights", arrival, minNights))
+	}
+	if maxNights != 0 && nights > maxNights {
+		return unprocessableError(CodeStayTooLong, fmt.Sprintf("Stays arriving on %s must be at most %d nights", arrival, maxNights))
+	}
+	return nil
+}
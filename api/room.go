@@ -1,21 +1,27 @@
 package api
 
 import (
-	"fmt"
-	"net/http"
+	"errors"
+	"mime/multipart"
+	"strconv"
 
 	"github.com/lancer2672/BookingAppSubServer/db"
-	"github.com/lancer2672/BookingAppSubServer/internal/utils"
 
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type createRoomRequest struct {
 	PropertyId uint   `form:"propertyId" binding:"required"`
-	Name       string `form:"name" binding:"required"`
-	Price      uint   `form:"price" binding:"required"`
-	AmenityIds []uint `form:"amenityIds" binding:"required"`
-	Images     []uint `form:"amenityIds" binding:"required"`
+	Name       string `form:"name" binding:"required,max=100"`
+	// Makes the room a unit of the type, taking its price and occupancy
+	RoomTypeId  uint                    `form:"roomTypeId"`
+	Price       uint                    `form:"price" binding:"required_without=RoomTypeId"`
+	MaxAdults   uint                    `form:"maxAdults" binding:"required_without=RoomTypeId,max=20"`
+	MaxChildren uint                    `form:"maxChildren" binding:"max=20"`
+	Beds        uint                    `form:"beds" binding:"required_without=RoomTypeId,max=20"`
+	AmenityIds  []uint                  `form:"amenityIds" binding:"required_without=RoomTypeId,dive,required"`
+	Images      []*multipart.FileHeader `form:"images"`
 }
 
 func (server *Server) createRoom(ctx *gin.Context) {
@@ -23,121 +29,166 @@ func (server *Server) createRoom(ctx *gin.Context) {
 
 	// Parse the form data
 	if err := ctx.ShouldBind(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondInvalid(ctx, err)
 		return
 	}
 
-	// Handle image uploads
-	form, err := ctx.MultipartForm()
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-	files := form.File["images"]
-
 	room := db.T_Rooms{
 		Fk_Property_Id: req.PropertyId,
 		Name:           req.Name,
 		Status:         "AVAILABLE",
 		Price:          req.Price,
+		Max_Adults:     req.MaxAdults,
+		Max_Children:   req.MaxChildren,
+		Beds:           req.Beds,
+	}
+	if req.RoomTypeId != 0 {
+		roomType, err := server.store.GetRoomType(ctx, req.RoomTypeId)
+		if errors.Is(err, db.ErrNotFound) {
+			err = errRoomTypeNotFound
+		}
+		if err != nil {
+			respondErr(ctx, err)
+			return
+		}
+		if roomType.Fk_Property_Id != req.PropertyId {
+			respondErr(ctx, errRoomNotInHotel)
+			return
+		}
+		room.Fk_Room_Type_Id = &roomType.Id
+		room.Price = roomType.Price
+		room.Max_Adults = roomType.Max_Adults
+		room.Max_Children = roomType.Max_Children
+		room.Beds = roomType.Beds
 	}
 
 	// Create room record in the database
-	if err := server.store.Create(&room).Error; err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := server.store.CreateRoom(ctx, &room); err != nil {
+		respondInternalError(ctx, err)
 		return
 	}
 
 	// Save uploaded images
-	for _, file := range files {
-		filePath := fmt.Sprintf("uploads/%s", file.Filename) // Customize this path as needed
-
-		// Save the file locally
-		if err := ctx.SaveUploadedFile(file, filePath); err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-		fullPath := fmt.Sprintf("%s/%s", utils.URL_API, filePath)
-		imagePath := &fullPath
-		// Create room image record in the database
-		roomImage := db.T_Room_Images{
-			Url:        *imagePath,
-			Fk_Room_Id: room.Id,
-		}
-		if err := server.store.Create(&roomImage).Error; err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	var imageUrls []string
+	for _, file := range req.Images {
+		url, err := server.saveUpload(ctx, file)
+		if err != nil {
+			respondUploadError(ctx, err)
 			return
 		}
+		imageUrls = append(imageUrls, url)
+	}
+
+	// Create room image records in the database
+	if err := server.store.AddRoomImages(ctx, room.Id, imageUrls); err != nil {
+		respondInternalError(ctx, err)
+		return
 	}
 
 	// Save room amenities
-	for _, amenityId := range req.AmenityIds {
-		roomAmenity := db.T_Room_Amenities{
-			Fk_Room_Id:    room.Id,
-			Fk_Amenity_Id: amenityId,
-		}
-		if err := server.store.Create(&roomAmenity).Error; err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
+	if err := server.store.AddRoomAmenities(ctx, room.Id, req.AmenityIds); err != nil {
+		respondInternalError(ctx, err)
+		return
 	}
 
-	ctx.JSON(http.StatusOK, RoomResponse{
-		ID:         room.Id,
-		PropertyID: room.Fk_Property_Id,
-		Name:       room.Name,
-		Status:     room.Status,
-		Price:      room.Price,
+	respondOK(ctx, RoomResponse{
+		ID:          room.Id,
+		PropertyID:  room.Fk_Property_Id,
+		RoomTypeID:  room.Fk_Room_Type_Id,
+		Name:        room.Name,
+		Status:      room.Status,
+		Price:       room.Price,
+		MaxAdults:   room.Max_Adults,
+		MaxChildren: room.Max_Children,
+		Beds:        room.Beds,
 	})
 }
 
 func (server *Server) getListRoomByHotelId(ctx *gin.Context) {
-	hotelId := ctx.Param("propertyId")
-
-	var rooms []db.T_Rooms
+	hotelId, err := strconv.ParseUint(ctx.Param("propertyId"), 10, 64)
+	if err != nil {
+		respondInvalid(ctx, FieldError{Field: "propertyId", Rule: "id"})
+		return
+	}
+	annotateSpan(ctx, attribute.Int64("property.id", int64(hotelId)))
 
 	// Query rooms by hotelId
-	if err := server.store.Where("fk_property_id = ?", hotelId).Find(&rooms).Error; err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching rooms"})
+	rooms, err := server.store.ListRoomsByProperties(ctx, []uint{uint(hotelId)}, "")
+	if err != nil {
+		respondInternalError(ctx, err)
 		return
 	}
 
-	var roomResponses []RoomResponse
+	roomResponses, err := server.buildRoomResponses(ctx, rooms)
+	if err != nil {
+		respondInternalError(ctx, err)
+		return
+	}
 
-	// Iterate through each room and fetch amenities and images
+	respondOK(ctx, roomResponses)
+}
+
+// buildRoomResponses fetches amenities and images for all rooms in two
+// queries and maps them onto RoomResponse values in the same order.
+func (server *Server) buildRoomResponses(ctx *gin.Context, rooms []db.T_Rooms) ([]RoomResponse, error) {
+	roomResponses := []RoomResponse{}
+	if len(rooms) == 0 {
+		return roomResponses, nil
+	}
+
+	roomIds := make([]uint, 0, len(rooms))
 	for _, room := range rooms {
-		var amenities = []AmenityResponse{}
-		if err := server.store.Table("t_room_amenities").
-			Select("t_amenities.id, t_amenities.name , t_amenities.type").
-			Joins("JOIN t_amenities ON t_amenities.id = t_room_amenities.fk_amenity_id").
-			Where("t_amenities.is_deleted = ?", false).
-			Where("t_room_amenities.fk_room_id = ?", room.Id).
-			Find(&amenities).Error; err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching amenities"})
-			return
-		}
+		roomIds = append(roomIds, room.Id)
+	}
 
-		var images = []ImageResponse{}
-		if err := server.store.Table("t_room_images").
-			Select("id, url").
-			Where("fk_room_id = ?", room.Id).
-			Find(&images).Error; err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching images"})
-			return
+	amenities, err := server.store.ListRoomAmenities(ctx, roomIds)
+	if err != nil {
+		return nil, err
+	}
+	amenitiesByRoom := map[uint][]AmenityResponse{}
+	for _, amenity := range amenities {
+		amenitiesByRoom[amenity.Fk_Room_Id] = append(amenitiesByRoom[amenity.Fk_Room_Id], AmenityResponse{
+			ID:   amenity.Id,
+			Name: amenity.Name,
+			Type: amenity.Type,
+		})
+	}
+
+	images, err := server.store.ListRoomImages(ctx, roomIds)
+	if err != nil {
+		return nil, err
+	}
+	imagesByRoom := map[uint][]ImageResponse{}
+	for _, image := range images {
+		imagesByRoom[image.Fk_Room_Id] = append(imagesByRoom[image.Fk_Room_Id], ImageResponse{
+			ID:  image.Id,
+			Url: image.Url,
+		})
+	}
+
+	for _, room := range rooms {
+		roomAmenities := amenitiesByRoom[room.Id]
+		if roomAmenities == nil {
+			roomAmenities = []AmenityResponse{}
+		}
+		roomImages := imagesByRoom[room.Id]
+		if roomImages == nil {
+			roomImages = []ImageResponse{}
 		}
-		roomResponse := RoomResponse{
+		roomResponses = append(roomResponses, RoomResponse{
 			ID:            room.Id,
 			PropertyID:    room.Fk_Property_Id,
+			RoomTypeID:    room.Fk_Room_Type_Id,
 			Name:          room.Name,
 			Status:        room.Status,
 			Price:         room.Price,
-			RoomAmenities: amenities,
-			RoomImages:    images,
-		}
-
-		roomResponses = append(roomResponses, roomResponse)
-
+			MaxAdults:     room.Max_Adults,
+			MaxChildren:   room.Max_Children,
+			Beds:          room.Beds,
+			RoomAmenities: roomAmenities,
+			RoomImages:    roomImages,
+		})
 	}
 
-	ctx.JSON(http.StatusOK, roomResponses)
+	return roomResponses, nil
 }
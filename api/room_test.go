@@ -0,0 +1,94 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/lancer2672/BookingAppSubServer/db"
+	"github.com/lancer2672/BookingAppSubServer/internal/utils"
+)
+
+func TestCreateRoom(t *testing.T) {
+	server, store := newTestServer(t)
+	property := createTestProperty(t, store, 7)
+	amenity := db.T_Amenities{Name: "Balcony"}
+	store.CreateAmenity(&amenity)
+
+	status, response := serveForm(t, server, http.MethodPost, "/api/rooms/", map[string][]string{
+		"propertyId": {fmt.Sprint(property.Id)},
+		"name":       {"Deluxe"},
+		"price":      {"800"},
+		"maxAdults":  {"2"},
+		"beds":       {"1"},
+		"amenityIds": {fmt.Sprint(amenity.Id)},
+	})
+	if status != http.StatusOK {
+		t.Fatalf("create: got %d %+v", status, response.Error)
+	}
+	var created RoomResponse
+	decodeData(t, response, &created)
+	if created.Status != utils.RoomStatusAvaiable || created.Price != 800 {
+		t.Fatalf("create: got %+v", created)
+	}
+
+	status, response = serveJSON(t, server, http.MethodGet, fmt.Sprintf("/api/rooms/%d", property.Id), nil)
+	if status != http.StatusOK {
+		t.Fatalf("list: got %d %+v", status, response.Error)
+	}
+	var rooms []RoomResponse
+	decodeData(t, response, &rooms)
+	if len(rooms) != 1 || rooms[0].ID != created.ID || len(rooms[0].RoomAmenities) != 1 || rooms[0].RoomAmenities[0].ID != amenity.Id {
+		t.Fatalf("list: got %+v", rooms)
+	}
+}
+
+func TestCreateRoomInvalid(t *testing.T) {
+	server, store := newTestServer(t)
+	property := createTestProperty(t, store, 7)
+
+	// Price, occupancy and amenities are required unless a room type is given
+	status, response := serveForm(t, server, http.MethodPost, "/api/rooms/", map[string][]string{
+		"propertyId": {fmt.Sprint(property.Id)},
+		"name":       {"Deluxe"},
+	})
+	expectError(t, status, response, http.StatusBadRequest, CodeValidationFailed)
+
+	status, response = serveForm(t, server, http.MethodPost, "/api/rooms/", map[string][]string{
+		"propertyId": {fmt.Sprint(property.Id)},
+		"name":       {"Deluxe"},
+		"roomTypeId": {"999"},
+	})
+	expectError(t, status, response, http.StatusNotFound, CodeRoomTypeNotFound)
+}
+
+func TestListRoomsInvalidProperty(t *testing.T) {
+	server, _ := newTestServer(t)
+
+	status, response := serveJSON(t, server, http.MethodGet, "/api/rooms/abc", nil)
+	expectError(t, status, response, http.StatusBadRequest, CodeValidationFailed)
+}
+
+func TestDeleteRoom(t *testing.T) {
+	server, store := newTestServer(t)
+	property := createTestProperty(t, store, 7)
+	room := createTestRoom(t, store, property.Id, 500)
+
+	status, response := serveJSON(t, server, http.MethodDelete, fmt.Sprintf("/api/rooms/%d", room.Id), nil)
+	if status != http.StatusOK {
+		t.Fatalf("delete: got %d %+v", status, response.Error)
+	}
+	status, response = serveJSON(t, server, http.MethodGet, fmt.Sprintf("/api/rooms/%d", property.Id), nil)
+	var rooms []RoomResponse
+	decodeData(t, response, &rooms)
+	if status != http.StatusOK || len(rooms) != 1 || rooms[0].Status != utils.RoomStatusDeleted {
+		t.Fatalf("list after delete: got %d %+v", status, rooms)
+	}
+
+	// A deleted room cannot be booked
+	status, response = bookRoom(t, server, room, 10, 1)
+	expectError(t, status, response, http.StatusUnprocessableEntity, CodeRoomNotAvailable)
+
+	status, response = serveJSON(t, server, http.MethodDelete, "/api/rooms/999", nil)
+	expectError(t, status, response, http.StatusNotFound, CodeRoomNotFound)
+}
@@ -0,0 +1,364 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"mime/multipart"
+	"slices"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lancer2672/BookingAppSubServer/db"
+	"github.com/lancer2672/BookingAppSubServer/internal/utils"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type createRoomTypeRequest struct {
+	PropertyId  uint                    `form:"propertyId" binding:"required"`
+	Name        string                  `form:"name" binding:"required,max=100"`
+	Price       uint                    `form:"price" binding:"required"`
+	MaxAdults   uint                    `form:"maxAdults" binding:"required,min=1,max=20"`
+	MaxChildren uint                    `form:"maxChildren" binding:"max=20"`
+	Beds        uint                    `form:"beds" binding:"required,min=1,max=20"`
+	AmenityIds  []uint                  `form:"amenityIds" binding:"required,dive,required"`
+	Images      []*multipart.FileHeader `form:"images"`
+}
+
+// RoomTypeResponse is a room type with the number of its units.
+type RoomTypeResponse struct {
+	ID          uint              `json:"id"`
+	PropertyID  uint              `json:"propertyId"`
+	Name        string            `json:"name"`
+	Status      string            `json:"status"`
+	Price       uint              `json:"price"`
+	MaxAdults   uint              `json:"maxAdults"`
+	MaxChildren uint              `json:"maxChildren"`
+	Beds        uint              `json:"beds"`
+	Units       int               `json:"units"`
+	Amenities   []AmenityResponse `json:"amenities"`
+	Images      []ImageResponse   `json:"images"`
+}
+
+func (server *Server) createRoomType(ctx *gin.Context) {
+	var req createRoomTypeRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		respondInvalid(ctx, err)
+		return
+	}
+	annotateSpan(ctx, attribute.Int64("property.id", int64(req.PropertyId)))
+
+	_, err := server.store.GetProperty(ctx, req.PropertyId)
+	if errors.Is(err, db.ErrNotFound) {
+		err = errHotelNotFound
+	}
+	if err != nil {
+		respondErr(ctx, err)
+		return
+	}
+
+	roomType := db.T_Room_Types{
+		Fk_Property_Id: req.PropertyId,
+		Name:           req.Name,
+		Status:         utils.RoomStatusAvaiable,
+		Price:          req.Price,
+		Max_Adults:     req.MaxAdults,
+		Max_Children:   req.MaxChildren,
+		Beds:           req.Beds,
+	}
+	if err := server.store.CreateRoomType(ctx, &roomType); err != nil {
+		respondInternalError(ctx, err)
+		return
+	}
+
+	var imageUrls []string
+	for _, file := range req.Images {
+		url, err := server.saveUpload(ctx, file)
+		if err != nil {
+			respondUploadError(ctx, err)
+			return
+		}
+		imageUrls = append(imageUrls, url)
+	}
+	if err := server.store.AddRoomTypeImages(ctx, roomType.Id, imageUrls); err != nil {
+		respondInternalError(ctx, err)
+		return
+	}
+	if err := server.store.AddRoomTypeAmenities(ctx, roomType.Id, req.AmenityIds); err != nil {
+		respondInternalError(ctx, err)
+		return
+	}
+
+	roomTypeResponses, err := server.buildRoomTypeResponses(ctx, []db.T_Room_Types{roomType})
+	if err != nil {
+		respondInternalError(ctx, err)
+		return
+	}
+	respondOK(ctx, roomTypeResponses[0])
+}
+
+func (server *Server) getRoomTypesByHotelId(ctx *gin.Context) {
+	hotelId, err := strconv.ParseUint(ctx.Param("propertyId"), 10, 64)
+	if err != nil {
+		respondInvalid(ctx, FieldError{Field: "propertyId", Rule: "id"})
+		return
+	}
+	annotateSpan(ctx, attribute.Int64("property.id", int64(hotelId)))
+
+	roomTypes, err := server.store.ListRoomTypesByProperties(ctx, []uint{uint(hotelId)})
+	if err != nil {
+		respondInternalError(ctx, err)
+		return
+	}
+	roomTypeResponses, err := server.buildRoomTypeResponses(ctx, roomTypes)
+	if err != nil {
+		respondInternalError(ctx, err)
+		return
+	}
+	respondOK(ctx, roomTypeResponses)
+}
+
+// buildRoomTypeResponses fetches the units, amenities and images of all room
+// types in three queries and maps them onto RoomTypeResponse values in the
+// same order.
+func (server *Server) buildRoomTypeResponses(ctx *gin.Context, roomTypes []db.T_Room_Types) ([]RoomTypeResponse, error) {
+	roomTypeResponses := []RoomTypeResponse{}
+	if len(roomTypes) == 0 {
+		return roomTypeResponses, nil
+	}
+
+	roomTypeIds := make([]uint, 0, len(roomTypes))
+	for _, roomType := range roomTypes {
+		roomTypeIds = append(roomTypeIds, roomType.Id)
+	}
+
+	units, err := server.store.ListRoomTypeUnits(ctx, roomTypeIds)
+	if err != nil {
+		return nil, err
+	}
+	unitsByType := map[uint]int{}
+	for _, unit := range units {
+		if unit.Status != utils.RoomStatusDeleted {
+			unitsByType[*unit.Fk_Room_Type_Id]++
+		}
+	}
+
+	amenities, err := server.store.ListRoomTypeAmenities(ctx, roomTypeIds)
+	if err != nil {
+		return nil, err
+	}
+	amenitiesByType := map[uint][]AmenityResponse{}
+	for _, amenity := range amenities {
+		amenitiesByType[amenity.Fk_Room_Type_Id] = append(amenitiesByType[amenity.Fk_Room_Type_Id], AmenityResponse{
+			ID:   amenity.Id,
+			Name: amenity.Name,
+			Type: amenity.Type,
+		})
+	}
+
+	images, err := server.store.ListRoomTypeImages(ctx, roomTypeIds)
+	if err != nil {
+		return nil, err
+	}
+	imagesByType := map[uint][]ImageResponse{}
+	for _, image := range images {
+		imagesByType[image.Fk_Room_Type_Id] = append(imagesByType[image.Fk_Room_Type_Id], ImageResponse{
+			ID:  image.Id,
+			Url: image.Url,
+		})
+	}
+
+	for _, roomType := range roomTypes {
+		typeAmenities := amenitiesByType[roomType.Id]
+		if typeAmenities == nil {
+			typeAmenities = []AmenityResponse{}
+		}
+		typeImages := imagesByType[roomType.Id]
+		if typeImages == nil {
+			typeImages = []ImageResponse{}
+		}
+		roomTypeResponses = append(roomTypeResponses, RoomTypeResponse{
+			ID:          roomType.Id,
+			PropertyID:  roomType.Fk_Property_Id,
+			Name:        roomType.Name,
+			Status:      roomType.Status,
+			Price:       roomType.Price,
+			MaxAdults:   roomType.Max_Adults,
+			MaxChildren: roomType.Max_Children,
+			Beds:        roomType.Beds,
+			Units:       unitsByType[roomType.Id],
+			Amenities:   typeAmenities,
+			Images:      typeImages,
+		})
+	}
+
+	return roomTypeResponses, nil
+}
+
+// assignUnits gives booking a unit for every room of a type it reserved
+// that has none yet. The rooms in roomIds are assigned first, as chosen by
+// staff; the rest are the free units of each type in ID order.
+func assignUnits(ctx context.Context, store db.Store, booking db.T_Bookings, roomIds []uint) error {
+	bookedTypes, err := store.ListBookedRoomTypes(ctx, []uint{booking.Id})
+	if err != nil {
+		return err
+	}
+	bookedRooms, err := store.ListBookedRooms(ctx, []uint{booking.Id})
+	if err != nil {
+		return err
+	}
+
+	missing := map[uint]int{}
+	guestsByType := map[uint]db.BookedRoomType{}
+	for _, bookedType := range bookedTypes {
+		missing[bookedType.Id] += int(bookedType.Quantity)
+		guestsByType[bookedType.Id] = bookedType
+	}
+	used := map[uint]bool{}
+	for _, room := range bookedRooms {
+		used[room.Id] = true
+		if room.Fk_Room_Type_Id != nil {
+			missing[*room.Fk_Room_Type_Id]--
+		}
+	}
+
+	var assigned []db.T_Booking_Rooms
+	assign := func(unit db.T_Rooms) {
+		roomTypeId := *unit.Fk_Room_Type_Id
+		assigned = append(assigned, db.T_Booking_Rooms{
+			Fk_Room_Id:    unit.Id,
+			Fk_Booking_id: booking.Id,
+			Adults:        guestsByType[roomTypeId].Adults,
+			Children:      guestsByType[roomTypeId].Children,
+		})
+		missing[roomTypeId]--
+		used[unit.Id] = true
+	}
+	// free reports whether unit can be given to booking
+	free := func(unit db.T_Rooms) (bool, error) {
+		if used[unit.Id] || unit.Status != utils.RoomStatusAvaiable {
+			return false, nil
+		}
+		overlapping, err := store.HasOverlappingBooking(ctx, unit.Id, booking.Start_Date, booking.End_Date, booking.Id)
+		return !overlapping, err
+	}
+
+	for _, roomId := range roomIds {
+		unit, err := store.GetRoom(ctx, roomId)
+		if errors.Is(err, db.ErrNotFound) {
+			return errRoomNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if unit.Fk_Room_Type_Id == nil || missing[*unit.Fk_Room_Type_Id] <= 0 || used[unit.Id] {
+			return errUnitNotNeeded
+		}
+		ok, err := free(unit)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errRoomAlreadyBooked
+		}
+		assign(unit)
+	}
+
+	for _, bookedType := range bookedTypes {
+		if missing[bookedType.Id] <= 0 {
+			continue
+		}
+		units, err := store.ListRoomTypeUnits(ctx, []uint{bookedType.Id})
+		if err != nil {
+			return err
+		}
+		for _, unit := range units {
+			if missing[bookedType.Id] == 0 {
+				break
+			}
+			ok, err := free(unit)
+			if err != nil {
+				return err
+			}
+			if ok {
+				assign(unit)
+			}
+		}
+		if missing[bookedType.Id] > 0 {
+			return errNoFreeUnit
+		}
+	}
+
+	return store.AddBookingRooms(ctx, assigned)
+}
+
+// checkRoomTypeAvailable returns an error unless quantity rooms of roomType
+// are free for the stay, leaving out exceptBookingId.
+func checkRoomTypeAvailable(ctx context.Context, store db.Store, roomType db.T_Room_Types, quantity uint, stay stay, exceptBookingId uint) error {
+	if roomType.Status != utils.RoomStatusAvaiable {
+		return errRoomNotAvailable
+	}
+	free, err := freeUnits(ctx, store, roomType.Id, stay, exceptBookingId)
+	if err != nil {
+		return err
+	}
+	if free < quantity {
+		return errRoomTypeSoldOut
+	}
+	return nil
+}
+
+// freeUnits counts the rooms of a type still free for the whole stay: the
+// units offered for booking, less the most rooms of the type reserved at
+// any one time during the stay.
+func freeUnits(ctx context.Context, store db.Store, roomTypeId uint, stay stay, exceptBookingId uint) (uint, error) {
+	units, err := store.ListRoomTypeUnits(ctx, []uint{roomTypeId})
+	if err != nil {
+		return 0, err
+	}
+	offered := uint(0)
+	for _, unit := range units {
+		if unit.Status == utils.RoomStatusAvaiable {
+			offered++
+		}
+	}
+	reservations, err := store.ListRoomTypeReservations(ctx, roomTypeId, stay.start, stay.end, exceptBookingId)
+	if err != nil {
+		return 0, err
+	}
+	if peak := peakReserved(reservations); peak < offered {
+		return offered - peak, nil
+	}
+	return 0, nil
+}
+
+// peakReserved is the largest number of rooms held at the same time by
+// reservations.
+func peakReserved(reservations []db.RoomTypeReservation) uint {
+	type event struct {
+		at    int64
+		delta int
+	}
+	events := make([]event, 0, 2*len(reservations))
+	for _, reservation := range reservations {
+		events = append(events,
+			event{at: reservation.Start.UnixNano(), delta: int(reservation.Quantity)},
+			event{at: reservation.End.UnixNano(), delta: -int(reservation.Quantity)},
+		)
+	}
+	// A stay ending when another starts does not overlap it
+	slices.SortFunc(events, func(a, b event) int {
+		if a.at != b.at {
+			if a.at < b.at {
+				return -1
+			}
+			return 1
+		}
+		return a.delta - b.delta
+	})
+	var held, peak int
+	for _, e := range events {
+		held += e.delta
+		peak = max(peak, held)
+	}
+	return uint(peak)
+}
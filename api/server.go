@@ -1,56 +1,95 @@
 package api
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/lancer2672/BookingAppSubServer/db"
+	"github.com/lancer2672/BookingAppSubServer/internal/storage"
 	"github.com/lancer2672/BookingAppSubServer/internal/utils"
-	"gorm.io/gorm"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 // Server serves HTTP requests for our banking service.
 type Server struct {
-	config utils.Config
-	store  *gorm.DB
+	config  utils.Config
+	store   db.Store
+	storage storage.Storage
 
-	router *gin.Engine
+	router  *gin.Engine
+	metrics *metrics
+	stays   stayDefaults
+
+	openAPIDocument []byte
 }
 
 // NewServer creates a new HTTP server and set up routing.
-func NewServer(config utils.Config, store *gorm.DB) (*Server, error) {
+func NewServer(config utils.Config, store db.Store) (*Server, error) {
 	// tokenMaker, err := token.NewPasetoMaker(config.TokenSymmetricKey)
 	// if err != nil {
 	// 	return nil, fmt.Errorf("cannot create token maker: %w", err)
 	// }
 
+	uploads, err := storage.New(config)
+	if err != nil {
+		return nil, err
+	}
+	stays, err := newStayDefaults(config)
+	if err != nil {
+		return nil, err
+	}
+
 	server := &Server{
-		config: config,
-		store:  store,
+		config:  config,
+		store:   store,
+		storage: uploads,
+		metrics: newMetrics(),
+		stays:   stays,
 	}
 
+	registerValidators()
 	server.setupRouter()
+
+	server.openAPIDocument, err = buildOpenAPI(server.router.Routes(), config.PublicURL)
+	if err != nil {
+		return nil, fmt.Errorf("cannot build OpenAPI document: %w", err)
+	}
 	return server, nil
 }
 
 func (server *Server) setupRouter() {
-	router := gin.Default()
-	// config := cors.DefaultConfig()
-	// config.AllowAllOrigins = true
-	// config.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
-	// config.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization"}
-
-	// router.Use(cors.New(config))
+	router := gin.New()
+	// Lets store calls made with the *gin.Context see the request-scoped
+	// logger and cancellation of the underlying request
+	router.ContextWithFallback = true
+	router.Use(otelgin.Middleware(server.config.TracingServiceName), requestLogger(), server.metrics.observeRequests(), gin.CustomRecovery(func(ctx *gin.Context, recovered any) {
+		respondInternalError(ctx, fmt.Errorf("panic: %v", recovered))
+	}))
+	router.NoRoute(func(ctx *gin.Context) {
+		respondError(ctx, http.StatusNotFound, CodeNotFound, "Route not found")
+	})
+	router.MaxMultipartMemory = server.config.UploadMaxBytes
+	router.Use(securityHeaders(server.config), corsMiddleware(server.config))
 
-	router.StaticFS("/uploads", gin.Dir("./uploads", true))
+	router.StaticFS("/uploads", gin.Dir(server.config.UploadDir, true))
 	router.POST("/api/booking/v2", server.createBookingV2)
 	// router.POST("/api/bookings", server.createBooking)
-	router.GET("/healthcheck", func(ctx *gin.Context) {
-		ctx.JSON(http.StatusOK, "OK")
-	})
+	router.GET("/openapi.json", server.openAPI)
+	router.GET("/docs", server.docs)
+	router.GET("/metrics", server.metrics.handler())
+	router.GET("/livez", server.livez)
+	router.GET("/readyz", server.readyz)
+	// Kept for clients of the old probe
+	router.GET("/healthcheck", server.healthcheck)
 	router.PATCH("/api/bookings", server.updateBookingStatus)
 	router.GET("/api/bookings/user/:userId", server.getListBookingByUserId)
 	router.GET("/api/bookings/agent/:agentId", server.getListBookingByAgentId)
 	router.GET("/api/bookings/:bookingId", server.getById)
+	router.PATCH("/api/bookings/:bookingId", server.modifyBooking)
 
 	router.POST("api/hotels", server.createHotel)
 	// router.POST("api/hotels/v2", server.createHotel)
@@ -58,9 +97,24 @@ func (server *Server) setupRouter() {
 	router.GET("api/hotels/:agentId", server.getHotelsByAgent)
 
 	router.GET("api/rooms/:propertyId", server.getListRoomByHotelId)
+	router.GET("api/rooms/:propertyId/availability", server.searchAvailableRooms)
 	router.POST("api/rooms/", server.createRoom)
 	router.DELETE("api/rooms/:roomId", server.deleteRoom)
 
+	router.POST("api/room-types", server.createRoomType)
+	router.GET("api/room-types/:propertyId", server.getRoomTypesByHotelId)
+
+	router.POST("api/stay-restrictions", server.createStayRestriction)
+	router.GET("api/stay-restrictions/:propertyId", server.getStayRestrictions)
+	router.DELETE("api/stay-restrictions/:restrictionId", server.deleteStayRestriction)
+
+	router.POST("api/promotions", server.createPromotion)
+	router.GET("api/promotions/:agentId", server.getPromotionsByAgent)
+
+	router.POST("api/charges", server.createCharge)
+	router.GET("api/charges/:propertyId", server.getCharges)
+	router.DELETE("api/charges/:chargeId", server.deleteCharge)
+
 	router.POST("api/banks/", server.CreateBankAccount)
 	router.PUT("api/banks/:bankId", server.updateBankAccount)
 	router.GET("api/banks/:agentId", server.GetListAccountByAgentId)
@@ -75,11 +129,37 @@ func (server *Server) setupRouter() {
 	server.router = router
 }
 
-// Start runs the HTTP server on a specific address.
-func (server *Server) Start(address string) error {
-	return server.router.Run(address)
-}
+// Start runs the HTTP server until ctx is canceled. It then stops accepting
+// connections and waits, at most ShutdownTimeout, for in-flight requests to
+// finish.
+func (server *Server) Start(ctx context.Context, address string) error {
+	httpServer := &http.Server{
+		Addr:         address,
+		Handler:      server.router,
+		ReadTimeout:  server.config.ReadTimeout,
+		WriteTimeout: server.config.WriteTimeout,
+		IdleTimeout:  server.config.IdleTimeout,
+	}
 
-func errorResponse(err error) gin.H {
-	return gin.H{"error": err.Error()}
+	serveErr := make(chan error, 1)
+	go func() {
+		log.Info().Str("address", address).Msg("HTTP server started")
+		serveErr <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Info().Msg("shutting down HTTP server")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), server.config.ShutdownTimeout)
+	defer cancel()
+
+	err := httpServer.Shutdown(shutdownCtx)
+	if serveErr := <-serveErr; !errors.Is(serveErr, http.ErrServerClosed) {
+		err = errors.Join(err, serveErr)
+	}
+	return err
 }
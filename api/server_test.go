@@ -0,0 +1,146 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lancer2672/BookingAppSubServer/db"
+	"github.com/lancer2672/BookingAppSubServer/internal/utils"
+	"github.com/rs/zerolog"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+	os.Exit(m.Run())
+}
+
+// newTestServer creates a server backed by an empty in-memory store.
+func newTestServer(t *testing.T) (*Server, *db.MemoryStore) {
+	t.Helper()
+	store := db.NewMemoryStore()
+	server, err := NewServer(utils.Config{
+		PublicURL:        "http://localhost:8080",
+		StorageBackend:   "local",
+		UploadDir:        t.TempDir(),
+		UploadMaxBytes:   1 << 20,
+		PropertyTimezone: "Asia/Ho_Chi_Minh",
+		CheckInTime:      "14:00",
+		CheckOutTime:     "12:00",
+	}, store)
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	return server, store
+}
+
+// testResponse is the response envelope with the data left undecoded.
+type testResponse struct {
+	Data    json.RawMessage `json:"data"`
+	Message string          `json:"message"`
+	Paging  *Paging         `json:"paging"`
+	Error   *ErrorBody      `json:"error"`
+}
+
+func serve(t *testing.T, server *Server, req *http.Request) (int, testResponse) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	server.router.ServeHTTP(w, req)
+	var response testResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("%s %s: cannot decode %q: %v", req.Method, req.URL, w.Body.String(), err)
+	}
+	return w.Code, response
+}
+
+// serveJSON sends body, when not nil, as JSON.
+func serveJSON(t *testing.T, server *Server, method, path string, body any) (int, testResponse) {
+	t.Helper()
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader = bytes.NewReader(encoded)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	return serve(t, server, req)
+}
+
+// serveForm sends fields as a multipart form, the way the upload endpoints
+// expect them.
+func serveForm(t *testing.T, server *Server, method, path string, fields map[string][]string) (int, testResponse) {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for name, values := range fields {
+		for _, value := range values {
+			if err := form.WriteField(name, value); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := form.Close(); err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(method, path, &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	return serve(t, server, req)
+}
+
+// decodeData decodes the data of response into v.
+func decodeData(t *testing.T, response testResponse, v any) {
+	t.Helper()
+	if err := json.Unmarshal(response.Data, v); err != nil {
+		t.Fatalf("cannot decode data %s: %v", response.Data, err)
+	}
+}
+
+// expectError fails the test unless the response has the status and code.
+func expectError(t *testing.T, status int, response testResponse, wantStatus int, wantCode string) {
+	t.Helper()
+	if status != wantStatus || response.Error == nil || response.Error.Code != wantCode {
+		t.Fatalf("got %d %+v, want %d %s", status, response.Error, wantStatus, wantCode)
+	}
+}
+
+func createTestProperty(t *testing.T, store db.Store, agentId uint) db.T_Properties {
+	t.Helper()
+	property := db.T_Properties{Name: "Hotel", Status: utils.HotelStatusAvaiable, Fk_Argent_Id: agentId}
+	if err := store.CreateProperty(context.Background(), &property); err != nil {
+		t.Fatal(err)
+	}
+	return property
+}
+
+func createTestRoom(t *testing.T, store db.Store, propertyId uint, price uint) db.T_Rooms {
+	t.Helper()
+	room := db.T_Rooms{
+		Fk_Property_Id: propertyId,
+		Name:           "Room",
+		Status:         utils.RoomStatusAvaiable,
+		Price:          price,
+		Max_Adults:     2,
+		Beds:           1,
+	}
+	if err := store.CreateRoom(context.Background(), &room); err != nil {
+		t.Fatal(err)
+	}
+	return room
+}
+
+// localDate is the date days from today, as sent in requests.
+func localDate(days int) string {
+	return time.Now().AddDate(0, 0, days).Format(time.DateOnly)
+}
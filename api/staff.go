@@ -1,72 +1,49 @@
 package api
 
 import (
-	"fmt"
-	"io"
-	"net/http"
-	"os"
+	"mime/multipart"
 	"strconv"
 
 	"github.com/lancer2672/BookingAppSubServer/db"
-	"github.com/lancer2672/BookingAppSubServer/internal/utils"
 
 	"github.com/gin-gonic/gin"
 )
 
+type createStaffRequest struct {
+	AgentId     uint                  `form:"agentId" binding:"required"`
+	FirstName   string                `form:"firstName" binding:"required,max=100"`
+	LastName    string                `form:"lastName" binding:"required,max=100"`
+	Email       string                `form:"email" binding:"required,email,max=100"`
+	PhoneNumber string                `form:"phoneNumber" binding:"required,numeric,max=15"`
+	Avatar      *multipart.FileHeader `form:"avatar"`
+}
+
 func (server *Server) CreateStaff(ctx *gin.Context) {
 	// Parse form data
-	err := ctx.Request.ParseMultipartForm(10 << 20) // 10MB maximum form size
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Cannot parse form data"})
-		return
-	}
-
-	// Retrieve fields from form data
-	agentIDStr := ctx.Request.FormValue("agentId")
-	agentID, err := strconv.ParseUint(agentIDStr, 10, 64)
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid agent ID"})
+	var req createStaffRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		respondInvalid(ctx, err)
 		return
 	}
 
-	firstName := ctx.Request.FormValue("firstName")
-	lastName := ctx.Request.FormValue("lastName")
-	email := ctx.Request.FormValue("email")
-	phoneNumber := ctx.Request.FormValue("phoneNumber")
 	role := "STAFF"
 	password := "$2a$10$sW1Loq.Jo8LAwuaXzCRcj.KeXSegN15xCZDLFfV3woiu0MaI8sc5."
-	avatar, avatarHeader, err := ctx.Request.FormFile("avatar")
 	var avatarURL string
-	if err == nil {
-		defer avatar.Close()
-
-		// Customize upload path and file name as needed
-		avatarPath := fmt.Sprintf("uploads/%s", avatarHeader.Filename)
-
-		// Save avatar file to server
-		out, err := os.Create(avatarPath)
+	if req.Avatar != nil {
+		var err error
+		avatarURL, err = server.saveUpload(ctx, req.Avatar)
 		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save avatar file"})
+			respondUploadError(ctx, err)
 			return
 		}
-		defer out.Close()
-
-		_, err = io.Copy(out, avatar)
-		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save avatar file"})
-			return
-		}
-		fullPath := fmt.Sprintf("%s/%s", utils.URL_API, avatarPath)
-
-		avatarURL = fullPath
 	}
 
 	// Create a new user (staff)
 	newUser := db.T_Users{
-		First_Name:   firstName,
-		Last_Name:    lastName,
-		Email:        &email,
-		Phone_Number: phoneNumber,
+		First_Name:   req.FirstName,
+		Last_Name:    req.LastName,
+		Email:        &req.Email,
+		Phone_Number: req.PhoneNumber,
 		Role:         role,
 		Avatar:       avatarURL,
 		Status:       "ACTIVE", // Assuming staff is active upon creation
@@ -74,24 +51,24 @@ func (server *Server) CreateStaff(ctx *gin.Context) {
 	}
 
 	// Save user to database
-	if err := server.store.Create(&newUser).Error; err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create staff"})
+	if err := server.store.CreateUser(ctx, &newUser); err != nil {
+		respondInternalError(ctx, err)
 		return
 	}
 
 	// Create agent-staff relationship
 	agentStaff := db.T_Agent_Staffs{
-		Agent_Id: uint(agentID),
+		Agent_Id: req.AgentId,
 		Staff_Id: newUser.Id,
 	}
 
 	// Save agent-staff relationship to database
-	if err := server.store.Create(&agentStaff).Error; err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create agent-staff relationship"})
+	if err := server.store.AddAgentStaff(ctx, &agentStaff); err != nil {
+		respondInternalError(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"message": "Staff created successfully", "staff": newUser})
+	respondMessage(ctx, "Staff created successfully", newUser)
 }
 
 type StaffResponse struct {
@@ -110,17 +87,14 @@ func (server *Server) GetStaffByAgentId(ctx *gin.Context) {
 	agentID := ctx.Param("agentId")
 	agentIDUint, err := strconv.ParseUint(agentID, 10, 64)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid agent ID"})
+		respondInvalid(ctx, FieldError{Field: "agentId", Rule: "id"})
 		return
 	}
 
 	// Query staffs by agent ID
-	var staffs []db.T_Users
-	if err := server.store.Table("t_users").
-		Joins("JOIN t_agent_staffs ON t_users.id = t_agent_staffs.staff_id").
-		Where("t_agent_staffs.agent_id = ?", agentIDUint).
-		Find(&staffs).Error; err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch staffs"})
+	staffs, err := server.store.ListStaffByAgent(ctx, uint(agentIDUint))
+	if err != nil {
+		respondInternalError(ctx, err)
 		return
 	}
 
@@ -139,5 +113,5 @@ func (server *Server) GetStaffByAgentId(ctx *gin.Context) {
 		})
 	}
 
-	ctx.JSON(http.StatusOK, staffResponses)
+	respondOK(ctx, staffResponses)
 }
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestCreateStaff(t *testing.T) {
+	server, _ := newTestServer(t)
+
+	status, response := serveForm(t, server, http.MethodPost, "/api/staffs", map[string][]string{
+		"agentId":     {"7"},
+		"firstName":   {"An"},
+		"lastName":    {"Tran"},
+		"email":       {"an.tran@example.com"},
+		"phoneNumber": {"0901234567"},
+	})
+	if status != http.StatusOK {
+		t.Fatalf("create: got %d %+v", status, response.Error)
+	}
+
+	status, response = serveJSON(t, server, http.MethodGet, "/api/staffs/7", nil)
+	if status != http.StatusOK {
+		t.Fatalf("list: got %d %+v", status, response.Error)
+	}
+	var staffs []StaffResponse
+	decodeData(t, response, &staffs)
+	if len(staffs) != 1 || staffs[0].Email != "an.tran@example.com" || staffs[0].Role != "STAFF" {
+		t.Fatalf("list: got %+v", staffs)
+	}
+
+	// Staff belong to their agent only
+	_, response = serveJSON(t, server, http.MethodGet, "/api/staffs/8", nil)
+	staffs = nil
+	decodeData(t, response, &staffs)
+	if len(staffs) != 0 {
+		t.Fatalf("other agent: got %+v", staffs)
+	}
+}
+
+func TestCreateStaffInvalid(t *testing.T) {
+	server, _ := newTestServer(t)
+
+	status, response := serveForm(t, server, http.MethodPost, "/api/staffs", map[string][]string{
+		"agentId":     {"7"},
+		"firstName":   {"An"},
+		"lastName":    {"Tran"},
+		"email":       {"not an email"},
+		"phoneNumber": {"0901234567"},
+	})
+	expectError(t, status, response, http.StatusBadRequest, CodeValidationFailed)
+
+	status, response = serveJSON(t, server, http.MethodGet, "/api/staffs/abc", nil)
+	expectError(t, status, response, http.StatusBadRequest, CodeValidationFailed)
+}
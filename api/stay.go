@@ -0,0 +1,257 @@
+package api
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lancer2672/BookingAppSubServer/db"
+	"github.com/lancer2672/BookingAppSubServer/internal/utils"
+)
+
+// timeOfDay is a check-in or check-out time in the local time of a
+// property.
+type timeOfDay struct {
+	hour, minute int
+}
+
+func parseTimeOfDay(value string) (timeOfDay, error) {
+	t, err := time.Parse(utils.TimeOfDayLayout, value)
+	if err != nil {
+		return timeOfDay{}, fmt.Errorf("invalid time of day %q", value)
+	}
+	return timeOfDay{hour: t.Hour(), minute: t.Minute()}, nil
+}
+
+// on returns the instant date reaches t in loc.
+func (t timeOfDay) on(date Date, loc *time.Location) time.Time {
+	year, month, day := date.Date()
+	return time.Date(year, month, day, t.hour, t.minute, 0, 0, loc)
+}
+
+func (t timeOfDay) before(other timeOfDay) bool {
+	return t.hour*60+t.minute < other.hour*60+other.minute
+}
+
+func (t timeOfDay) String() string {
+	return fmt.Sprintf("%02d:%02d", t.hour, t.minute)
+}
+
+// locations caches loaded time zones by name, as loading one reads the
+// zoneinfo database.
+var locations sync.Map
+
+func loadLocation(name string) (*time.Location, error) {
+	if location, ok := locations.Load(name); ok {
+		return location.(*time.Location), nil
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	locations.Store(name, location)
+	return location, nil
+}
+
+// stayPolicy holds the check-in and check-out rules of a property.
+type stayPolicy struct {
+	location *time.Location
+	checkIn  timeOfDay
+	checkOut timeOfDay
+	// nil when the property does not offer the option
+	earlyCheckIn  *timeOfDay
+	lateCheckOut  *timeOfDay
+	checkInWindow time.Duration
+}
+
+// stayDefaults are the server-wide rules used for properties that do not
+// set their own check-in and check-out times.
+type stayDefaults struct {
+	location      *time.Location
+	checkIn       timeOfDay
+	checkOut      timeOfDay
+	checkInWindow time.Duration
+}
+
+func newStayDefaults(config utils.Config) (stayDefaults, error) {
+	location, err := loadLocation(config.PropertyTimezone)
+	if err != nil {
+		return stayDefaults{}, fmt.Errorf("cannot load property time zone: %w", err)
+	}
+	checkIn, err := parseTimeOfDay(config.CheckInTime)
+	if err != nil {
+		return stayDefaults{}, fmt.Errorf("CHECK_IN_TIME: %w", err)
+	}
+	checkOut, err := parseTimeOfDay(config.CheckOutTime)
+	if err != nil {
+		return stayDefaults{}, fmt.Errorf("CHECK_OUT_TIME: %w", err)
+	}
+	return stayDefaults{
+		location:      location,
+		checkIn:       checkIn,
+		checkOut:      checkOut,
+		checkInWindow: config.CheckInWindow,
+	}, nil
+}
+
+// defaultPolicy returns the rules of a property that sets none of its own.
+func (defaults stayDefaults) defaultPolicy() stayPolicy {
+	return stayPolicy{
+		location:      defaults.location,
+		checkIn:       defaults.checkIn,
+		checkOut:      defaults.checkOut,
+		checkInWindow: defaults.checkInWindow,
+	}
+}
+
+// policy returns the rules of property, filling in the defaults.
+func (defaults stayDefaults) policy(property db.T_Properties) (stayPolicy, error) {
+	policy := defaults.defaultPolicy()
+
+	var err error
+	if property.Timezone != "" {
+		if policy.location, err = loadLocation(property.Timezone); err != nil {
+			return policy, fmt.Errorf("property %d: %w", property.Id, err)
+		}
+	}
+	if property.Check_In_Time != "" {
+		if policy.checkIn, err = parseTimeOfDay(property.Check_In_Time); err != nil {
+			return policy, fmt.Errorf("property %d: %w", property.Id, err)
+		}
+	}
+	if property.Check_Out_Time != "" {
+		if policy.checkOut, err = parseTimeOfDay(property.Check_Out_Time); err != nil {
+			return policy, fmt.Errorf("property %d: %w", property.Id, err)
+		}
+	}
+	if property.Early_Check_In_Time != nil {
+		early, err := parseTimeOfDay(*property.Early_Check_In_Time)
+		if err != nil {
+			return policy, fmt.Errorf("property %d: %w", property.Id, err)
+		}
+		policy.earlyCheckIn = &early
+	}
+	if property.Late_Check_Out_Time != nil {
+		late, err := parseTimeOfDay(*property.Late_Check_Out_Time)
+		if err != nil {
+			return policy, fmt.Errorf("property %d: %w", property.Id, err)
+		}
+		policy.lateCheckOut = &late
+	}
+	return policy, nil
+}
+
+// StayTimes are the effective check-in and check-out times of a property,
+// in its local time. Early and late times are only set when offered.
+type StayTimes struct {
+	Timezone         string  `json:"timezone"`
+	CheckInTime      string  `json:"checkInTime"`
+	CheckOutTime     string  `json:"checkOutTime"`
+	EarlyCheckInTime *string `json:"earlyCheckInTime"`
+	LateCheckOutTime *string `json:"lateCheckOutTime"`
+}
+
+func (policy stayPolicy) times() StayTimes {
+	times := StayTimes{
+		Timezone:     policy.location.String(),
+		CheckInTime:  policy.checkIn.String(),
+		CheckOutTime: policy.checkOut.String(),
+	}
+	if policy.earlyCheckIn != nil {
+		early := policy.earlyCheckIn.String()
+		times.EarlyCheckInTime = &early
+	}
+	if policy.lateCheckOut != nil {
+		late := policy.lateCheckOut.String()
+		times.LateCheckOutTime = &late
+	}
+	return times
+}
+
+// checkOptions returns a FieldError when the early check-in or late
+// check-out time of policy does not extend the stay.
+func (policy stayPolicy) checkOptions() error {
+	if early := policy.earlyCheckIn; early != nil && !early.before(policy.checkIn) {
+		return FieldError{Field: "earlyCheckInTime", Rule: "ltfield", param: "checkInTime"}
+	}
+	if late := policy.lateCheckOut; late != nil && !policy.checkOut.before(*late) {
+		return FieldError{Field: "lateCheckOutTime", Rule: "gtfield", param: "checkOutTime"}
+	}
+	return nil
+}
+
+// stay is a booked stay: the instants the guest may arrive and must leave,
+// and the number of nights billed.
+type stay struct {
+	start  time.Time
+	end    time.Time
+	nights int
+}
+
+// stay turns the local arrival and departure dates of a request into a
+// stay of whole nights. The instants come from the property times and are
+// returned in UTC.
+func (policy stayPolicy) stay(arrival, departure Date, earlyCheckIn, lateCheckOut bool) (stay, error) {
+	checkIn, checkOut := policy.checkIn, policy.checkOut
+	if earlyCheckIn {
+		if policy.earlyCheckIn == nil {
+			return stay{}, errEarlyCheckInNotOffered
+		}
+		checkIn = *policy.earlyCheckIn
+	}
+	if lateCheckOut {
+		if policy.lateCheckOut == nil {
+			return stay{}, errLateCheckOutNotOffered
+		}
+		checkOut = *policy.lateCheckOut
+	}
+	return stay{
+		start:  checkIn.on(arrival, policy.location).UTC(),
+		end:    checkOut.on(departure, policy.location).UTC(),
+		nights: stayNights(arrival, departure),
+	}, nil
+}
+
+// stayNights counts the nights between arrival and departure.
+func stayNights(arrival, departure Date) int {
+	return int(departure.Sub(arrival.Time).Hours() / 24)
+}
+
+// sharedNights counts the nights two stays have in common.
+func sharedNights(arrival, departure, otherArrival, otherDeparture Date) int {
+	if otherArrival.After(arrival.Time) {
+		arrival = otherArrival
+	}
+	if otherDeparture.Before(departure.Time) {
+		departure = otherDeparture
+	}
+	return max(0, stayNights(arrival, departure))
+}
+
+// today is the current date at the property.
+func (policy stayPolicy) today(now time.Time) Date {
+	return dateOf(now.In(policy.location))
+}
+
+// localDate is the date at the property of the instant t.
+func (policy stayPolicy) localDate(t time.Time) Date {
+	return dateOf(t.In(policy.location))
+}
+
+// checkCheckIn returns an error unless now is within CHECK_IN_WINDOW of the
+// start of booking. Any time is allowed when the window is 0.
+func (policy stayPolicy) checkCheckIn(booking db.T_Bookings, now time.Time) error {
+	if policy.checkInWindow == 0 {
+		return nil
+	}
+	from, until := booking.Start_Date, booking.Start_Date.Add(policy.checkInWindow)
+	if now.Before(from) || now.After(until) {
+		const layout = "2006-01-02 15:04 MST"
+		return unprocessableError(CodeCheckInNotAllowed, fmt.Sprintf(
+			"Check-in is only allowed from %s until %s",
+			from.In(policy.location).Format(layout),
+			until.In(policy.location).Format(layout),
+		))
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lancer2672/BookingAppSubServer/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// errFileTooLarge is returned by saveUpload for files above UploadMaxBytes.
+type errFileTooLarge struct {
+	name  string
+	limit int64
+}
+
+func (e errFileTooLarge) Error() string {
+	return fmt.Sprintf("file %q is larger than %d bytes", e.name, e.limit)
+}
+
+// saveUpload stores an uploaded file in the configured storage and returns
+// its public URL.
+func (server *Server) saveUpload(ctx *gin.Context, file *multipart.FileHeader) (string, error) {
+	if file.Size > server.config.UploadMaxBytes {
+		return "", errFileTooLarge{name: file.Filename, limit: server.config.UploadMaxBytes}
+	}
+
+	spanCtx, span := tracing.Tracer.Start(ctx.Request.Context(), "storage.Save", trace.WithAttributes(
+		attribute.String("upload.filename", file.Filename),
+		attribute.Int64("upload.size", file.Size),
+	))
+	defer span.End()
+
+	content, err := file.Open()
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return "", err
+	}
+	defer content.Close()
+
+	url, err := server.storage.Save(spanCtx, file.Filename, content)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return "", err
+	}
+	server.metrics.uploadedFiles.Inc()
+	server.metrics.uploadedBytes.Add(float64(file.Size))
+	return url, nil
+}
+
+// respondUploadError answers for an error returned by saveUpload.
+func respondUploadError(ctx *gin.Context, err error) {
+	var tooLarge errFileTooLarge
+	if errors.As(err, &tooLarge) {
+		respondError(ctx, http.StatusRequestEntityTooLarge, CodeFileTooLarge, tooLarge.Error())
+		return
+	}
+	respondInternalError(ctx, err)
+}
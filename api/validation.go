@@ -0,0 +1,240 @@
+package api
+
+import (
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/lancer2672/BookingAppSubServer/internal/utils"
+	"golang.org/x/text/language"
+)
+
+// maxStayNights is the longest stay a single booking may cover.
+const maxStayNights = 30
+
+// Languages validation messages are available in. English is the fallback
+// for any other Accept-Language.
+const (
+	languageEnglish    = "en"
+	languageVietnamese = "vi"
+)
+
+var languageMatcher = language.NewMatcher([]language.Tag{language.English, language.Vietnamese})
+
+// validationMessages holds, per language, the message of each validation
+// rule and the summaries used in ErrorBody.Message. "{param}" is replaced by
+// the parameter of the rule, e.g. the 1 of min=1.
+var validationMessages = map[string]map[string]string{
+	languageEnglish: {
+		"request.invalid":   "Request validation failed",
+		"request.json":      "Request body is not valid JSON",
+		"request.malformed": "Request could not be parsed",
+		"rule.unknown":      "failed the {param} rule",
+
+		"required":         "is required",
+		"required_without": "is required unless {param} is given",
+		"excluded_with":    "must not be given together with {param}",
+		"gte":              "must be at least {param}",
+		"restriction":      "must be set, or one of maxNights, closedToArrival and closedToDeparture",
+		"min":              "must be at least {param}",
+		"max":              "must be at most {param}",
+		"len":              "must have length {param}",
+		"gt":               "must be greater than {param}",
+		"oneof":            "must be one of {param}",
+		"unique":           "must not contain duplicates",
+		"email":            "must be a valid email address",
+		"numeric":          "must only contain digits",
+		"alphanum":         "must only contain letters and digits",
+		"latitude":         "must be a latitude between -90 and 90",
+		"longitude":        "must be a longitude between -180 and 180",
+		"gtfield":          "must be after {param}",
+		"gtefield":         "must not be before {param}",
+		"notpast":          "must not be in the past",
+		"minstay":          "must be at least {param} night after startDate",
+		"maxstay":          "must be at most {param} nights after startDate",
+		"lastroom":         "must leave at least one room in the booking",
+		"roomguests":       "must only give the guests of booked rooms, once each",
+		"timezone":         "must be an IANA time zone such as Asia/Ho_Chi_Minh",
+		"ltfield":          "must be before {param}",
+		"datetime":         "must be formatted as {param}",
+		"type":             "must be of type {param}",
+		"id":               "must be a positive integer",
+		"date":             "must be a date (YYYY-MM-DD) or an RFC 3339 timestamp",
+		"cursor":           "is not a valid cursor",
+		"cursormismatch":   "does not match the requested sortBy and order",
+	},
+	languageVietnamese: {
+		"request.invalid":   "Dữ liệu yêu cầu không hợp lệ",
+		"request.json":      "Nội dung yêu cầu không phải JSON hợp lệ",
+		"request.malformed": "Không thể đọc dữ liệu yêu cầu",
+		"rule.unknown":      "không thỏa mãn quy tắc {param}",
+
+		"required":         "là bắt buộc",
+		"required_without": "là bắt buộc nếu không có {param}",
+		"excluded_with":    "không được dùng cùng với {param}",
+		"gte":              "phải tối thiểu là {param}",
+		"restriction":      "phải được đặt, hoặc một trong maxNights, closedToArrival và closedToDeparture",
+		"min":              "phải tối thiểu là {param}",
+		"max":              "phải tối đa là {param}",
+		"len":              "phải có độ dài {param}",
+		"gt":               "phải lớn hơn {param}",
+		"oneof":            "phải là một trong các giá trị {param}",
+		"unique":           "không được chứa giá trị trùng lặp",
+		"email":            "phải là địa chỉ email hợp lệ",
+		"numeric":          "chỉ được chứa chữ số",
+		"alphanum":         "chỉ được chứa chữ cái và chữ số",
+		"latitude":         "phải là vĩ độ trong khoảng -90 đến 90",
+		"longitude":        "phải là kinh độ trong khoảng -180 đến 180",
+		"gtfield":          "phải sau {param}",
+		"gtefield":         "không được trước {param}",
+		"notpast":          "không được ở trong quá khứ",
+		"minstay":          "phải cách startDate ít nhất {param} đêm",
+		"maxstay":          "phải cách startDate tối đa {param} đêm",
+		"lastroom":         "phải giữ lại ít nhất một phòng trong đơn đặt phòng",
+		"roomguests":       "chỉ được khai báo số khách của các phòng được đặt, mỗi phòng một lần",
+		"timezone":         "phải là múi giờ IANA, ví dụ Asia/Ho_Chi_Minh",
+		"ltfield":          "phải trước {param}",
+		"datetime":         "phải có định dạng {param}",
+		"type":             "phải có kiểu {param}",
+		"id":               "phải là số nguyên dương",
+		"date":             "phải là ngày (YYYY-MM-DD) hoặc thời điểm theo RFC 3339",
+		"cursor":           "không phải con trỏ hợp lệ",
+		"cursormismatch":   "không khớp với sortBy và order được yêu cầu",
+	},
+}
+
+// requestLanguage picks the language of validation messages from the
+// Accept-Language header.
+func requestLanguage(ctx *gin.Context) string {
+	tags, _, _ := language.ParseAcceptLanguage(ctx.GetHeader("Accept-Language"))
+	_, index, confidence := languageMatcher.Match(tags...)
+	if confidence == language.No || index == 0 {
+		return languageEnglish
+	}
+	return languageVietnamese
+}
+
+// localize returns the message of key in lang, with param substituted.
+func localize(lang, key, param string) string {
+	messages := validationMessages[lang]
+	message, ok := messages[key]
+	if !ok {
+		message, param = messages["rule.unknown"], key
+	}
+	if key == "oneof" {
+		param = strings.ReplaceAll(param, " ", ", ")
+	}
+	return strings.ReplaceAll(message, "{param}", param)
+}
+
+// registerValidators sets up the validator used by gin binding: field names
+// are reported by their json or form name, and the custom rules and struct
+// level checks of the request types are registered.
+func registerValidators() {
+	validate, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		for _, tag := range []string{"json", "form", "uri"} {
+			name := strings.Split(field.Tag.Get(tag), ",")[0]
+			if name == "-" {
+				return ""
+			}
+			if name != "" {
+				return name
+			}
+		}
+		return field.Name
+	})
+	// Validate dates as the instants they wrap, so required rejects zero,
+	// or as the text that failed to parse, which the date rule rejects
+	validate.RegisterCustomTypeFunc(func(field reflect.Value) any {
+		date := field.Interface().(Date)
+		if date.invalid != "" {
+			return date.invalid
+		}
+		return date.Time
+	}, Date{})
+	validate.RegisterValidation("date", func(fl validator.FieldLevel) bool {
+		_, ok := fl.Field().Interface().(time.Time)
+		return ok
+	})
+	validate.RegisterStructValidation(validateBookingRequest, bookingRequest{})
+	validate.RegisterStructValidation(validateAvailabilityRequest, availabilityRequest{})
+	validate.RegisterStructValidation(validateStayRestrictionRequest, createStayRestrictionRequest{})
+	validate.RegisterStructValidation(validatePromotionRequest, createPromotionRequest{})
+	validate.RegisterStructValidation(validateChargeRequest, createChargeRequest{})
+}
+
+func validateBookingRequest(sl validator.StructLevel) {
+	req := sl.Current().Interface().(bookingRequest)
+	validateStayLength(sl, req.StartDate, req.EndDate)
+
+	if len(req.RoomIds) == 0 && len(req.RoomTypes) == 0 {
+		sl.ReportError(req.RoomIds, "roomIds", "RoomIds", "required_without", "RoomTypes")
+		return
+	}
+	// Guests only go in booked rooms; unique=RoomId already rules out a
+	// room listed twice
+	for _, guests := range req.Guests {
+		if !slices.Contains(req.RoomIds, guests.RoomId) {
+			sl.ReportError(req.Guests, "guests", "Guests", "roomguests", "")
+			return
+		}
+	}
+}
+
+func validateAvailabilityRequest(sl validator.StructLevel) {
+	req := sl.Current().Interface().(availabilityRequest)
+	validateStayLength(sl, req.StartDate, req.EndDate)
+}
+
+func validateStayRestrictionRequest(sl validator.StructLevel) {
+	req := sl.Current().Interface().(createStayRestrictionRequest)
+	if !req.StartDate.IsZero() && req.EndDate.Before(req.StartDate.Time) {
+		sl.ReportError(req.EndDate, "endDate", "EndDate", "gtefield", "StartDate")
+	}
+	if req.MaxNights != 0 && req.MaxNights < req.MinNights {
+		sl.ReportError(req.MaxNights, "maxNights", "MaxNights", "gte", "minNights")
+	}
+	if req.MinNights == 0 && req.MaxNights == 0 && !req.ClosedToArrival && !req.ClosedToDeparture {
+		sl.ReportError(req.MinNights, "minNights", "MinNights", "restriction", "")
+	}
+}
+
+func validatePromotionRequest(sl validator.StructLevel) {
+	req := sl.Current().Interface().(createPromotionRequest)
+	if !req.StartDate.IsZero() && req.EndDate.Before(req.StartDate.Time) {
+		sl.ReportError(req.EndDate, "endDate", "EndDate", "gtefield", "StartDate")
+	}
+	if req.Type == utils.PromotionType_Percentage && req.Value > 100 {
+		sl.ReportError(req.Value, "value", "Value", "max", "100")
+	}
+}
+
+func validateChargeRequest(sl validator.StructLevel) {
+	req := sl.Current().Interface().(createChargeRequest)
+	if req.Basis == utils.ChargeBasis_Percentage && req.Value > 100 {
+		sl.ReportError(req.Value, "value", "Value", "max", "100")
+	}
+}
+
+// validateStayLength reports an endDate that does not give a stay of 1 to
+// maxStayNights nights.
+func validateStayLength(sl validator.StructLevel, startDate, endDate Date) {
+	if startDate.IsZero() || endDate.IsZero() {
+		return
+	}
+	switch nights := stayNights(startDate, endDate); {
+	case nights < 1:
+		sl.ReportError(endDate, "endDate", "EndDate", "minstay", "1")
+	case nights > maxStayNights:
+		sl.ReportError(endDate, "endDate", "EndDate", "maxstay", strconv.Itoa(maxStayNights))
+	}
+}
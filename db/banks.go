@@ -0,0 +1,42 @@
+package db
+
+import (
+	"context"
+)
+
+type BankStore interface {
+	GetBank(ctx context.Context, id uint) (T_Banks, error)
+	ListBanksByAgent(ctx context.Context, agentId uint) ([]T_Banks, error)
+	CreateBank(ctx context.Context, bank *T_Banks) error
+	UpdateBank(ctx context.Context, bank *T_Banks) error
+	// ClearDefaultBank unsets Is_Default on every bank account of the agent
+	// except exceptId.
+	ClearDefaultBank(ctx context.Context, agentId uint, exceptId uint) error
+}
+
+func (store *PostgresStore) GetBank(ctx context.Context, id uint) (T_Banks, error) {
+	var bank T_Banks
+	err := store.conn(ctx).Where("id = ?", id).First(&bank).Error
+	return bank, notFound(err)
+}
+
+func (store *PostgresStore) ListBanksByAgent(ctx context.Context, agentId uint) ([]T_Banks, error) {
+	var banks []T_Banks
+	err := store.conn(ctx).Where("fk_argent_id = ?", agentId).Order("id").Find(&banks).Error
+	return banks, err
+}
+
+func (store *PostgresStore) CreateBank(ctx context.Context, bank *T_Banks) error {
+	return store.conn(ctx).Create(bank).Error
+}
+
+func (store *PostgresStore) UpdateBank(ctx context.Context, bank *T_Banks) error {
+	return store.conn(ctx).Save(bank).Error
+}
+
+func (store *PostgresStore) ClearDefaultBank(ctx context.Context, agentId uint, exceptId uint) error {
+	return store.conn(ctx).Model(&T_Banks{}).
+		Where("fk_argent_id = ?", agentId).
+		Where("id <> ?", exceptId).
+		Update("is_default", false).Error
+}
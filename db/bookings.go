@@ -0,0 +1,362 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lancer2672/BookingAppSubServer/internal/utils"
+)
+
+// Columns a booking list can be sorted by.
+const (
+	BookingSortCreatedAt  = "create_at"
+	BookingSortStartDate  = "start_date"
+	BookingSortEndDate    = "end_date"
+	BookingSortTotalPrice = "total_price"
+)
+
+// BookingCursor is the sort key and ID of the last booking of a page.
+// Time is used for date sort columns and Price for BookingSortTotalPrice.
+type BookingCursor struct {
+	Time  time.Time
+	Price float64
+	Id    uint
+}
+
+// BookingListParams filters, sorts and limits a booking list. Zero values
+// mean "no filter".
+type BookingListParams struct {
+	UserId     uint
+	AgentId    uint
+	PropertyId uint
+	Statuses   []string
+	// ByCreated applies From/To to the creation date instead of the stay.
+	ByCreated  bool
+	From       time.Time
+	To         time.Time
+	SortBy     string
+	Descending bool
+	After      *BookingCursor
+	Limit      int
+}
+
+// BookedRoom is a room together with the booking it is reserved for, the
+// guests staying in it and its booked price.
+type BookedRoom struct {
+	Fk_Booking_Id uint
+	Adults        uint
+	Children      uint
+	Amount        float64
+	T_Rooms
+}
+
+// BookedRoomType is a room type together with the booking reserving it, the
+// number of rooms reserved, the guests staying in each and their booked
+// price.
+type BookedRoomType struct {
+	Fk_Booking_Id uint
+	Quantity      uint
+	Adults        uint
+	Children      uint
+	Amount        float64
+	T_Room_Types
+}
+
+// RoomTypeReservation is the number of rooms of a type a booking holds
+// during its stay.
+type RoomTypeReservation struct {
+	Start    time.Time
+	End      time.Time
+	Quantity uint
+}
+
+type BookingStore interface {
+	GetBooking(ctx context.Context, id uint) (T_Bookings, error)
+	ListBookings(ctx context.Context, params BookingListParams) ([]T_Bookings, error)
+	CreateBooking(ctx context.Context, booking *T_Bookings) error
+	UpdateBooking(ctx context.Context, booking *T_Bookings) error
+	// HasOverlappingBooking reports whether roomId is reserved by any booking
+	// other than exceptBookingId whose stay overlaps [start, end). Pass 0 to
+	// consider every booking. Canceled bookings reserve nothing.
+	HasOverlappingBooking(ctx context.Context, roomId uint, start, end time.Time, exceptBookingId uint) (bool, error)
+	// ListOverlappingRoomIds returns which of roomIds are reserved by a
+	// booking, other than a canceled one, whose stay overlaps [start, end).
+	ListOverlappingRoomIds(ctx context.Context, roomIds []uint, start, end time.Time) ([]uint, error)
+
+	AddBookingRooms(ctx context.Context, bookingRooms []T_Booking_Rooms) error
+	// UpdateBookingRoomGuests returns ErrNotFound when the room is not part
+	// of the booking.
+	UpdateBookingRoomGuests(ctx context.Context, bookingId, roomId, adults, children uint) error
+	// UpdateBookingRoomAmount returns ErrNotFound when the room is not part
+	// of the booking.
+	UpdateBookingRoomAmount(ctx context.Context, bookingId, roomId uint, amount float64) error
+	RemoveBookingRooms(ctx context.Context, bookingId uint, roomIds []uint) error
+
+	AddBookingRoomTypes(ctx context.Context, bookingRoomTypes []T_Booking_Room_Types) error
+	ListBookedRoomTypes(ctx context.Context, bookingIds []uint) ([]BookedRoomType, error)
+	// UpdateBookingRoomTypeAmount returns ErrNotFound when the room type is
+	// not part of the booking.
+	UpdateBookingRoomTypeAmount(ctx context.Context, bookingId, roomTypeId uint, amount float64) error
+	// ListRoomTypeReservations returns the reservations of roomTypeId made by
+	// bookings other than exceptBookingId whose stay overlaps [start, end).
+	// Canceled bookings reserve nothing.
+	ListRoomTypeReservations(ctx context.Context, roomTypeId uint, start, end time.Time, exceptBookingId uint) ([]RoomTypeReservation, error)
+	ListBookedRooms(ctx context.Context, bookingIds []uint) ([]BookedRoom, error)
+
+	CreateBookingDeposit(ctx context.Context, deposit *T_Booking_Deposits) error
+	ListBookingDeposits(ctx context.Context, bookingIds []uint) ([]T_Booking_Deposits, error)
+
+	AddBookingStatusHistory(ctx context.Context, history *T_Booking_Status_Histories) error
+	ListBookingStatusHistory(ctx context.Context, bookingId uint) ([]T_Booking_Status_Histories, error)
+
+	// SetBookingLineItems replaces the line items of the booking.
+	SetBookingLineItems(ctx context.Context, bookingId uint, items []T_Booking_Line_Items) error
+	ListBookingLineItems(ctx context.Context, bookingIds []uint) ([]T_Booking_Line_Items, error)
+}
+
+func (store *PostgresStore) GetBooking(ctx context.Context, id uint) (T_Bookings, error) {
+	var booking T_Bookings
+	err := store.conn(ctx).Where("id = ?", id).First(&booking).Error
+	return booking, notFound(err)
+}
+
+func (store *PostgresStore) ListBookings(ctx context.Context, params BookingListParams) ([]T_Bookings, error) {
+	query := store.conn(ctx).Model(&T_Bookings{})
+
+	if params.UserId != 0 {
+		query = query.Where("t_bookings.fk_user_id = ?", params.UserId)
+	}
+	if params.AgentId != 0 {
+		agentProperties := store.conn(ctx).Model(&T_Properties{}).Select("id").Where("fk_argent_id = ?", params.AgentId)
+		query = query.Where("t_bookings.fk_property_id IN (?)", agentProperties)
+	}
+	if params.PropertyId != 0 {
+		query = query.Where("t_bookings.fk_property_id = ?", params.PropertyId)
+	}
+	if len(params.Statuses) > 0 {
+		query = query.Where("t_bookings.status IN ?", params.Statuses)
+	}
+
+	if !params.From.IsZero() {
+		if params.ByCreated {
+			query = query.Where("t_bookings.create_at >= ?", params.From)
+		} else {
+			query = query.Where("t_bookings.end_date > ?", params.From)
+		}
+	}
+	if !params.To.IsZero() {
+		if params.ByCreated {
+			query = query.Where("t_bookings.create_at < ?", params.To)
+		} else {
+			query = query.Where("t_bookings.start_date < ?", params.To)
+		}
+	}
+
+	sortBy := params.SortBy
+	if sortBy == "" {
+		sortBy = BookingSortCreatedAt
+	}
+	column := "t_bookings." + sortBy
+	comparison := ">"
+	direction := "ASC"
+	if params.Descending {
+		comparison = "<"
+		direction = "DESC"
+	}
+
+	if params.After != nil {
+		var value interface{} = params.After.Time
+		if sortBy == BookingSortTotalPrice {
+			value = params.After.Price
+		}
+		query = query.Where(fmt.Sprintf("(%s, t_bookings.id) %s (?, ?)", column, comparison), value, params.After.Id)
+	}
+
+	query = query.Order(fmt.Sprintf("%s %s, t_bookings.id %s", column, direction, direction))
+	if params.Limit > 0 {
+		query = query.Limit(params.Limit)
+	}
+
+	var bookings []T_Bookings
+	err := query.Find(&bookings).Error
+	return bookings, err
+}
+
+func (store *PostgresStore) CreateBooking(ctx context.Context, booking *T_Bookings) error {
+	return store.conn(ctx).Create(booking).Error
+}
+
+func (store *PostgresStore) UpdateBooking(ctx context.Context, booking *T_Bookings) error {
+	return store.conn(ctx).Save(booking).Error
+}
+
+func (store *PostgresStore) HasOverlappingBooking(ctx context.Context, roomId uint, start, end time.Time, exceptBookingId uint) (bool, error) {
+	var count int64
+	err := store.conn(ctx).Model(&T_Bookings{}).
+		Joins("JOIN t_booking_rooms ON t_booking_rooms.fk_booking_id = t_bookings.id").
+		Where("t_booking_rooms.fk_room_id = ? AND ((t_bookings.start_date, t_bookings.end_date) OVERLAPS (?, ?))", roomId, start, end).
+		Where("t_bookings.id <> ? AND t_bookings.status <> ?", exceptBookingId, utils.BookingStatus_Canceled).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (store *PostgresStore) ListOverlappingRoomIds(ctx context.Context, roomIds []uint, start, end time.Time) ([]uint, error) {
+	var overlapping []uint
+	if len(roomIds) == 0 {
+		return overlapping, nil
+	}
+	err := store.conn(ctx).Model(&T_Booking_Rooms{}).
+		Distinct("t_booking_rooms.fk_room_id").
+		Joins("JOIN t_bookings ON t_bookings.id = t_booking_rooms.fk_booking_id").
+		Where("t_booking_rooms.fk_room_id IN ? AND ((t_bookings.start_date, t_bookings.end_date) OVERLAPS (?, ?))", roomIds, start, end).
+		Where("t_bookings.status <> ?", utils.BookingStatus_Canceled).
+		Pluck("t_booking_rooms.fk_room_id", &overlapping).Error
+	return overlapping, err
+}
+
+func (store *PostgresStore) AddBookingRooms(ctx context.Context, bookingRooms []T_Booking_Rooms) error {
+	if len(bookingRooms) == 0 {
+		return nil
+	}
+	return store.conn(ctx).Create(&bookingRooms).Error
+}
+
+func (store *PostgresStore) UpdateBookingRoomGuests(ctx context.Context, bookingId, roomId, adults, children uint) error {
+	result := store.conn(ctx).Model(&T_Booking_Rooms{}).
+		Where("fk_booking_id = ? AND fk_room_id = ?", bookingId, roomId).
+		Updates(map[string]interface{}{"adults": adults, "children": children})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (store *PostgresStore) RemoveBookingRooms(ctx context.Context, bookingId uint, roomIds []uint) error {
+	if len(roomIds) == 0 {
+		return nil
+	}
+	return store.conn(ctx).
+		Where("fk_booking_id = ? AND fk_room_id IN ?", bookingId, roomIds).
+		Delete(&T_Booking_Rooms{}).Error
+}
+
+func (store *PostgresStore) ListBookedRooms(ctx context.Context, bookingIds []uint) ([]BookedRoom, error) {
+	var rooms []BookedRoom
+	if len(bookingIds) == 0 {
+		return rooms, nil
+	}
+	err := store.conn(ctx).Table("t_rooms").
+		Select("t_rooms.*, t_booking_rooms.fk_booking_id, t_booking_rooms.adults, t_booking_rooms.children, t_booking_rooms.amount").
+		Joins("JOIN t_booking_rooms ON t_booking_rooms.fk_room_id = t_rooms.id").
+		Where("t_booking_rooms.fk_booking_id IN ?", bookingIds).
+		Order("t_booking_rooms.id").
+		Scan(&rooms).Error
+	return rooms, err
+}
+
+func (store *PostgresStore) UpdateBookingRoomAmount(ctx context.Context, bookingId, roomId uint, amount float64) error {
+	result := store.conn(ctx).Model(&T_Booking_Rooms{}).
+		Where("fk_booking_id = ? AND fk_room_id = ?", bookingId, roomId).
+		Update("amount", amount)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (store *PostgresStore) AddBookingRoomTypes(ctx context.Context, bookingRoomTypes []T_Booking_Room_Types) error {
+	if len(bookingRoomTypes) == 0 {
+		return nil
+	}
+	return store.conn(ctx).Create(&bookingRoomTypes).Error
+}
+
+func (store *PostgresStore) ListBookedRoomTypes(ctx context.Context, bookingIds []uint) ([]BookedRoomType, error) {
+	var roomTypes []BookedRoomType
+	if len(bookingIds) == 0 {
+		return roomTypes, nil
+	}
+	err := store.conn(ctx).Table("t_room_types").
+		Select("t_room_types.*, t_booking_room_types.fk_booking_id, t_booking_room_types.quantity, t_booking_room_types.adults, t_booking_room_types.children, t_booking_room_types.amount").
+		Joins("JOIN t_booking_room_types ON t_booking_room_types.fk_room_type_id = t_room_types.id").
+		Where("t_booking_room_types.fk_booking_id IN ?", bookingIds).
+		Order("t_booking_room_types.id").
+		Scan(&roomTypes).Error
+	return roomTypes, err
+}
+
+func (store *PostgresStore) UpdateBookingRoomTypeAmount(ctx context.Context, bookingId, roomTypeId uint, amount float64) error {
+	result := store.conn(ctx).Model(&T_Booking_Room_Types{}).
+		Where("fk_booking_id = ? AND fk_room_type_id = ?", bookingId, roomTypeId).
+		Update("amount", amount)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (store *PostgresStore) ListRoomTypeReservations(ctx context.Context, roomTypeId uint, start, end time.Time, exceptBookingId uint) ([]RoomTypeReservation, error) {
+	var reservations []RoomTypeReservation
+	err := store.conn(ctx).Table("t_booking_room_types").
+		Select(`t_bookings.start_date AS "start", t_bookings.end_date AS "end", t_booking_room_types.quantity`).
+		Joins("JOIN t_bookings ON t_bookings.id = t_booking_room_types.fk_booking_id").
+		Where("t_booking_room_types.fk_room_type_id = ? AND ((t_bookings.start_date, t_bookings.end_date) OVERLAPS (?, ?))", roomTypeId, start, end).
+		Where("t_bookings.id <> ? AND t_bookings.status <> ?", exceptBookingId, utils.BookingStatus_Canceled).
+		Scan(&reservations).Error
+	return reservations, err
+}
+
+func (store *PostgresStore) CreateBookingDeposit(ctx context.Context, deposit *T_Booking_Deposits) error {
+	return store.conn(ctx).Create(deposit).Error
+}
+
+func (store *PostgresStore) ListBookingDeposits(ctx context.Context, bookingIds []uint) ([]T_Booking_Deposits, error) {
+	var deposits []T_Booking_Deposits
+	if len(bookingIds) == 0 {
+		return deposits, nil
+	}
+	err := store.conn(ctx).Where("fk_booking_id IN ?", bookingIds).Order("id").Find(&deposits).Error
+	return deposits, err
+}
+
+func (store *PostgresStore) AddBookingStatusHistory(ctx context.Context, history *T_Booking_Status_Histories) error {
+	return store.conn(ctx).Create(history).Error
+}
+
+func (store *PostgresStore) ListBookingStatusHistory(ctx context.Context, bookingId uint) ([]T_Booking_Status_Histories, error) {
+	var histories []T_Booking_Status_Histories
+	err := store.conn(ctx).Where("fk_booking_id = ?", bookingId).Order("create_at, id").Find(&histories).Error
+	return histories, err
+}
+
+func (store *PostgresStore) SetBookingLineItems(ctx context.Context, bookingId uint, items []T_Booking_Line_Items) error {
+	if err := store.conn(ctx).Where("fk_booking_id = ?", bookingId).Delete(&T_Booking_Line_Items{}).Error; err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		return nil
+	}
+	for i := range items {
+		items[i].Fk_Booking_Id = bookingId
+	}
+	return store.conn(ctx).Create(&items).Error
+}
+
+func (store *PostgresStore) ListBookingLineItems(ctx context.Context, bookingIds []uint) ([]T_Booking_Line_Items, error) {
+	var items []T_Booking_Line_Items
+	if len(bookingIds) == 0 {
+		return items, nil
+	}
+	err := store.conn(ctx).Where("fk_booking_id IN ?", bookingIds).Order("id").Find(&items).Error
+	return items, err
+}
@@ -0,0 +1,33 @@
+package db
+
+import (
+	"context"
+)
+
+type PropertyChargeStore interface {
+	CreatePropertyCharge(ctx context.Context, charge *T_Property_Charges) error
+	ListPropertyCharges(ctx context.Context, propertyId uint) ([]T_Property_Charges, error)
+	// DeletePropertyCharge returns ErrNotFound when no row has the given id.
+	DeletePropertyCharge(ctx context.Context, id uint) error
+}
+
+func (store *PostgresStore) CreatePropertyCharge(ctx context.Context, charge *T_Property_Charges) error {
+	return store.conn(ctx).Create(charge).Error
+}
+
+func (store *PostgresStore) ListPropertyCharges(ctx context.Context, propertyId uint) ([]T_Property_Charges, error) {
+	var charges []T_Property_Charges
+	err := store.conn(ctx).Where("fk_property_id = ?", propertyId).Order("id").Find(&charges).Error
+	return charges, err
+}
+
+func (store *PostgresStore) DeletePropertyCharge(ctx context.Context, id uint) error {
+	result := store.conn(ctx).Where("id = ?", id).Delete(&T_Property_Charges{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
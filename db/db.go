@@ -1,34 +1,35 @@
 package db
 
 import (
-	"fmt"
-
 	"github.com/lancer2672/BookingAppSubServer/internal/utils"
+	"github.com/rs/zerolog/log"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	gormtracing "gorm.io/plugin/opentelemetry/tracing"
 )
 
 func ConnectDatabase(config utils.Config) *gorm.DB {
-	db, err := gorm.Open(postgres.Open(config.DBSource), &gorm.Config{})
+	db, err := gorm.Open(postgres.Open(config.DBSource), &gorm.Config{
+		Logger: NewQueryLogger(config.SlowQueryThreshold),
+	})
 	if err != nil {
 		panic("failed to connect to database")
 	}
-	fmt.Println("Connected to db")
-	// Assign the database instance to the store variable
-
-	// Migrate the schema
-	// db.AutoMigrate(
-	// 	&User{},
-	// 	&Property{},
-	// 	&Room{},
-	// 	&Amenity{},
-	// 	&RoomImage{},
-	// 	&PropertyAmenity{},
-	// 	&PropertyImage{},
-	// 	&Booking{},
-	// 	&Province{},
-	// 	&District{},
-	// 	&Ward{},
-	// )
+	// One span per query; bound values are left out as they may hold
+	// personal data
+	if err := db.Use(gormtracing.NewPlugin(gormtracing.WithoutMetrics(), gormtracing.WithoutQueryVariables())); err != nil {
+		panic("failed to set up query tracing")
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		panic("failed to connect to database")
+	}
+	sqlDB.SetMaxOpenConns(config.DBMaxOpenConns)
+	sqlDB.SetMaxIdleConns(config.DBMaxIdleConns)
+	sqlDB.SetConnMaxLifetime(config.DBConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(config.DBConnMaxIdleTime)
+	log.Info().Msg("Connected to db")
+	// The schema is managed by the versioned migrations in db/migration,
+	// see the "migrate" command in main.go.
 	return db
 }
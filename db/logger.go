@@ -0,0 +1,77 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"time"
+
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// queryLogger sends gorm's logs to the zerolog logger of the context the
+// query ran with, so SQL lines carry the request ID of the request that
+// issued them. Queries slower than slowThreshold are logged as warnings,
+// other queries only at debug level. The SQL is logged with placeholders,
+// as the bound values may hold personal data.
+type queryLogger struct {
+	slowThreshold time.Duration
+}
+
+func NewQueryLogger(slowThreshold time.Duration) logger.Interface {
+	return queryLogger{slowThreshold: slowThreshold}
+}
+
+// explainedPlaceholder matches the placeholders of a query explained
+// without its values, which the postgres dialector turns from $1 into $1$.
+var explainedPlaceholder = regexp.MustCompile(`\$(\d+)\$`)
+
+// ParamsFilter drops the bound values before gorm explains a query for
+// Trace, leaving only the statement.
+func (l queryLogger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	return sql, nil
+}
+
+// LogMode is a no-op, the level is taken from zerolog.
+func (l queryLogger) LogMode(logger.LogLevel) logger.Interface {
+	return l
+}
+
+func (l queryLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	zerolog.Ctx(ctx).Info().Msgf(msg, args...)
+}
+
+func (l queryLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	zerolog.Ctx(ctx).Warn().Msgf(msg, args...)
+}
+
+func (l queryLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	zerolog.Ctx(ctx).Error().Msgf(msg, args...)
+}
+
+func (l queryLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	log := zerolog.Ctx(ctx)
+	elapsed := time.Since(begin)
+
+	var event *zerolog.Event
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound) && !errors.Is(err, context.Canceled):
+		event = log.Error().Err(err)
+	case elapsed > l.slowThreshold:
+		event = log.Warn().Bool("slow", true)
+	default:
+		event = log.Debug()
+	}
+	if !event.Enabled() {
+		return
+	}
+
+	sql, rows := fc()
+	sql = explainedPlaceholder.ReplaceAllString(sql, "$$$1")
+	event.Dur("elapsed", elapsed).
+		Int64("rows", rows).
+		Str("sql", sql).
+		Msg("query")
+}
@@ -0,0 +1,160 @@
+package db
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// MemoryStore is an in-memory Store for handler tests and local development
+// without Postgres. Every table is a slice ordered by ID and all access is
+// serialized by a single mutex.
+type MemoryStore struct {
+	mu   *sync.Mutex
+	data *memoryTables
+	inTx bool
+	// Store calls made, shared with the copies used by ExecTx
+	queries *atomic.Int64
+}
+
+type memoryTables struct {
+	lastId uint
+
+	users       []T_Users
+	agentStaffs []T_Agent_Staffs
+	amenities   []T_Amenities
+
+	properties        []T_Properties
+	propertyImages    []T_Property_Images
+	propertyAmenities []T_Property_Amenities
+
+	rooms         []T_Rooms
+	roomImages    []T_Room_Images
+	roomAmenities []T_Room_Amenities
+
+	roomTypes         []T_Room_Types
+	roomTypeImages    []T_Room_Type_Images
+	roomTypeAmenities []T_Room_Type_Amenities
+
+	stayRestrictions []T_Stay_Restrictions
+
+	promotions       []T_Promotions
+	promotionTargets []T_Promotion_Targets
+
+	propertyCharges []T_Property_Charges
+
+	bookings         []T_Bookings
+	bookingRooms     []T_Booking_Rooms
+	bookingRoomTypes []T_Booking_Room_Types
+	bookingDeposits  []T_Booking_Deposits
+	bookingHistories []T_Booking_Status_Histories
+	bookingLineItems []T_Booking_Line_Items
+
+	banks []T_Banks
+}
+
+// NewMemoryStore creates an empty in-memory Store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		mu:      &sync.Mutex{},
+		data:    &memoryTables{},
+		queries: &atomic.Int64{},
+	}
+}
+
+// Queries returns the number of store calls made so far. Each stands for
+// one query of PostgresStore, which lets tests check how the number of
+// queries of a handler grows with the data.
+func (store *MemoryStore) Queries() int64 {
+	return store.queries.Load()
+}
+
+// Ping always succeeds, the tables live in memory.
+func (store *MemoryStore) Ping(ctx context.Context) error {
+	return ctx.Err()
+}
+
+// ExecTx runs fn against a copy of the tables and publishes the copy only
+// when fn succeeds, which gives the same all-or-nothing result as a database
+// transaction. Transactions are serialized.
+func (store *MemoryStore) ExecTx(ctx context.Context, fn func(Store) error) error {
+	if store.inTx {
+		return fn(store)
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	tx := &MemoryStore{mu: store.mu, data: store.data.clone(), inTx: true, queries: store.queries}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	*store.data = *tx.data
+	return nil
+}
+
+// CreateAmenity adds an amenity so rooms and properties can reference it.
+// Amenities are managed outside this service, so only the memory store
+// needs a way to create them.
+func (store *MemoryStore) CreateAmenity(amenity *T_Amenities) {
+	defer store.lock()()
+	amenity.Id = store.data.nextId()
+	store.data.amenities = append(store.data.amenities, *amenity)
+}
+
+// lock acquires the store mutex unless the store is already running inside
+// ExecTx, and returns the matching unlock function. Every store call takes
+// it once, so it also counts the call.
+func (store *MemoryStore) lock() func() {
+	store.queries.Add(1)
+	if store.inTx {
+		return func() {}
+	}
+	store.mu.Lock()
+	return store.mu.Unlock
+}
+
+func (t *memoryTables) nextId() uint {
+	t.lastId++
+	return t.lastId
+}
+
+func (t *memoryTables) clone() *memoryTables {
+	c := *t
+	c.users = slices.Clone(t.users)
+	c.agentStaffs = slices.Clone(t.agentStaffs)
+	c.amenities = slices.Clone(t.amenities)
+	c.properties = slices.Clone(t.properties)
+	c.propertyImages = slices.Clone(t.propertyImages)
+	c.propertyAmenities = slices.Clone(t.propertyAmenities)
+	c.rooms = slices.Clone(t.rooms)
+	c.roomImages = slices.Clone(t.roomImages)
+	c.roomAmenities = slices.Clone(t.roomAmenities)
+	c.roomTypes = slices.Clone(t.roomTypes)
+	c.roomTypeImages = slices.Clone(t.roomTypeImages)
+	c.roomTypeAmenities = slices.Clone(t.roomTypeAmenities)
+	c.stayRestrictions = slices.Clone(t.stayRestrictions)
+	c.promotions = slices.Clone(t.promotions)
+	c.promotionTargets = slices.Clone(t.promotionTargets)
+	c.propertyCharges = slices.Clone(t.propertyCharges)
+	c.bookings = slices.Clone(t.bookings)
+	c.bookingRooms = slices.Clone(t.bookingRooms)
+	c.bookingRoomTypes = slices.Clone(t.bookingRoomTypes)
+	c.bookingDeposits = slices.Clone(t.bookingDeposits)
+	c.bookingHistories = slices.Clone(t.bookingHistories)
+	c.bookingLineItems = slices.Clone(t.bookingLineItems)
+	c.banks = slices.Clone(t.banks)
+	return &c
+}
+
+func (t *memoryTables) amenity(id uint) (T_Amenities, bool) {
+	for _, amenity := range t.amenities {
+		if amenity.Id == id {
+			return amenity, true
+		}
+	}
+	return T_Amenities{}, false
+}
+
+var _ Store = (*MemoryStore)(nil)
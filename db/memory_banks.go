@@ -0,0 +1,54 @@
+package db
+
+import (
+	"context"
+)
+
+func (store *MemoryStore) GetBank(ctx context.Context, id uint) (T_Banks, error) {
+	defer store.lock()()
+	for _, bank := range store.data.banks {
+		if bank.ID == id {
+			return bank, nil
+		}
+	}
+	return T_Banks{}, ErrNotFound
+}
+
+func (store *MemoryStore) ListBanksByAgent(ctx context.Context, agentId uint) ([]T_Banks, error) {
+	defer store.lock()()
+	var banks []T_Banks
+	for _, bank := range store.data.banks {
+		if bank.Fk_Argent_Id == agentId {
+			banks = append(banks, bank)
+		}
+	}
+	return banks, nil
+}
+
+func (store *MemoryStore) CreateBank(ctx context.Context, bank *T_Banks) error {
+	defer store.lock()()
+	bank.ID = store.data.nextId()
+	store.data.banks = append(store.data.banks, *bank)
+	return nil
+}
+
+func (store *MemoryStore) UpdateBank(ctx context.Context, bank *T_Banks) error {
+	defer store.lock()()
+	for i := range store.data.banks {
+		if store.data.banks[i].ID == bank.ID {
+			store.data.banks[i] = *bank
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (store *MemoryStore) ClearDefaultBank(ctx context.Context, agentId uint, exceptId uint) error {
+	defer store.lock()()
+	for i := range store.data.banks {
+		if store.data.banks[i].Fk_Argent_Id == agentId && store.data.banks[i].ID != exceptId {
+			store.data.banks[i].Is_Default = false
+		}
+	}
+	return nil
+}
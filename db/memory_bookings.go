@@ -0,0 +1,360 @@
+package db
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	"github.com/lancer2672/BookingAppSubServer/internal/utils"
+)
+
+func (store *MemoryStore) GetBooking(ctx context.Context, id uint) (T_Bookings, error) {
+	defer store.lock()()
+	for _, booking := range store.data.bookings {
+		if booking.Id == id {
+			return booking, nil
+		}
+	}
+	return T_Bookings{}, ErrNotFound
+}
+
+func (store *MemoryStore) ListBookings(ctx context.Context, params BookingListParams) ([]T_Bookings, error) {
+	defer store.lock()()
+
+	agentProperties := map[uint]bool{}
+	if params.AgentId != 0 {
+		for _, property := range store.data.properties {
+			if property.Fk_Argent_Id == params.AgentId {
+				agentProperties[property.Id] = true
+			}
+		}
+	}
+
+	sortBy := params.SortBy
+	if sortBy == "" {
+		sortBy = BookingSortCreatedAt
+	}
+	compare := func(a T_Bookings, cursor BookingCursor) int {
+		var order int
+		switch sortBy {
+		case BookingSortStartDate:
+			order = a.Start_Date.Compare(cursor.Time)
+		case BookingSortEndDate:
+			order = a.End_Date.Compare(cursor.Time)
+		case BookingSortTotalPrice:
+			order = cmp.Compare(a.Total_Price, cursor.Price)
+		default:
+			order = a.Create_At.Compare(cursor.Time)
+		}
+		if order == 0 {
+			order = cmp.Compare(a.Id, cursor.Id)
+		}
+		if params.Descending {
+			order = -order
+		}
+		return order
+	}
+
+	var bookings []T_Bookings
+	for _, booking := range store.data.bookings {
+		if params.UserId != 0 && booking.Fk_User_Id != params.UserId {
+			continue
+		}
+		if params.AgentId != 0 && !agentProperties[booking.Fk_Property_Id] {
+			continue
+		}
+		if params.PropertyId != 0 && booking.Fk_Property_Id != params.PropertyId {
+			continue
+		}
+		if len(params.Statuses) > 0 && !slices.Contains(params.Statuses, booking.Status) {
+			continue
+		}
+		if !params.From.IsZero() {
+			if params.ByCreated && booking.Create_At.Before(params.From) {
+				continue
+			}
+			if !params.ByCreated && !booking.End_Date.After(params.From) {
+				continue
+			}
+		}
+		if !params.To.IsZero() {
+			if params.ByCreated && !booking.Create_At.Before(params.To) {
+				continue
+			}
+			if !params.ByCreated && !booking.Start_Date.Before(params.To) {
+				continue
+			}
+		}
+		if params.After != nil && compare(booking, *params.After) <= 0 {
+			continue
+		}
+		bookings = append(bookings, booking)
+	}
+
+	slices.SortFunc(bookings, func(a, b T_Bookings) int {
+		return compare(a, BookingCursor{
+			Time:  sortTime(b, sortBy),
+			Price: b.Total_Price,
+			Id:    b.Id,
+		})
+	})
+	if params.Limit > 0 && len(bookings) > params.Limit {
+		bookings = bookings[:params.Limit]
+	}
+	return bookings, nil
+}
+
+func sortTime(booking T_Bookings, sortBy string) time.Time {
+	switch sortBy {
+	case BookingSortStartDate:
+		return booking.Start_Date
+	case BookingSortEndDate:
+		return booking.End_Date
+	default:
+		return booking.Create_At
+	}
+}
+
+func (store *MemoryStore) CreateBooking(ctx context.Context, booking *T_Bookings) error {
+	defer store.lock()()
+	booking.Id = store.data.nextId()
+	store.data.bookings = append(store.data.bookings, *booking)
+	return nil
+}
+
+func (store *MemoryStore) UpdateBooking(ctx context.Context, booking *T_Bookings) error {
+	defer store.lock()()
+	for i := range store.data.bookings {
+		if store.data.bookings[i].Id == booking.Id {
+			store.data.bookings[i] = *booking
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+// reserves reports whether booking holds its rooms during [start, end).
+func reserves(booking T_Bookings, start, end time.Time) bool {
+	return booking.Status != utils.BookingStatus_Canceled && booking.Start_Date.Before(end) && start.Before(booking.End_Date)
+}
+
+func (store *MemoryStore) HasOverlappingBooking(ctx context.Context, roomId uint, start, end time.Time, exceptBookingId uint) (bool, error) {
+	defer store.lock()()
+	for _, bookingRoom := range store.data.bookingRooms {
+		if bookingRoom.Fk_Room_Id != roomId || bookingRoom.Fk_Booking_id == exceptBookingId {
+			continue
+		}
+		for _, booking := range store.data.bookings {
+			if booking.Id == bookingRoom.Fk_Booking_id && reserves(booking, start, end) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func (store *MemoryStore) ListOverlappingRoomIds(ctx context.Context, roomIds []uint, start, end time.Time) ([]uint, error) {
+	defer store.lock()()
+	var overlapping []uint
+	for _, bookingRoom := range store.data.bookingRooms {
+		if !slices.Contains(roomIds, bookingRoom.Fk_Room_Id) || slices.Contains(overlapping, bookingRoom.Fk_Room_Id) {
+			continue
+		}
+		for _, booking := range store.data.bookings {
+			if booking.Id == bookingRoom.Fk_Booking_id && reserves(booking, start, end) {
+				overlapping = append(overlapping, bookingRoom.Fk_Room_Id)
+				break
+			}
+		}
+	}
+	return overlapping, nil
+}
+
+func (store *MemoryStore) AddBookingRooms(ctx context.Context, bookingRooms []T_Booking_Rooms) error {
+	defer store.lock()()
+	for _, bookingRoom := range bookingRooms {
+		bookingRoom.Id = store.data.nextId()
+		store.data.bookingRooms = append(store.data.bookingRooms, bookingRoom)
+	}
+	return nil
+}
+
+func (store *MemoryStore) UpdateBookingRoomGuests(ctx context.Context, bookingId, roomId, adults, children uint) error {
+	defer store.lock()()
+	for i, bookingRoom := range store.data.bookingRooms {
+		if bookingRoom.Fk_Booking_id == bookingId && bookingRoom.Fk_Room_Id == roomId {
+			store.data.bookingRooms[i].Adults = adults
+			store.data.bookingRooms[i].Children = children
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (store *MemoryStore) RemoveBookingRooms(ctx context.Context, bookingId uint, roomIds []uint) error {
+	defer store.lock()()
+	store.data.bookingRooms = slices.DeleteFunc(store.data.bookingRooms, func(bookingRoom T_Booking_Rooms) bool {
+		return bookingRoom.Fk_Booking_id == bookingId && slices.Contains(roomIds, bookingRoom.Fk_Room_Id)
+	})
+	return nil
+}
+
+func (store *MemoryStore) ListBookedRooms(ctx context.Context, bookingIds []uint) ([]BookedRoom, error) {
+	defer store.lock()()
+	var rooms []BookedRoom
+	for _, bookingRoom := range store.data.bookingRooms {
+		if !slices.Contains(bookingIds, bookingRoom.Fk_Booking_id) {
+			continue
+		}
+		for _, room := range store.data.rooms {
+			if room.Id == bookingRoom.Fk_Room_Id {
+				rooms = append(rooms, BookedRoom{
+					Fk_Booking_Id: bookingRoom.Fk_Booking_id,
+					Adults:        bookingRoom.Adults,
+					Children:      bookingRoom.Children,
+					Amount:        bookingRoom.Amount,
+					T_Rooms:       room,
+				})
+			}
+		}
+	}
+	return rooms, nil
+}
+
+func (store *MemoryStore) UpdateBookingRoomAmount(ctx context.Context, bookingId, roomId uint, amount float64) error {
+	defer store.lock()()
+	for i, bookingRoom := range store.data.bookingRooms {
+		if bookingRoom.Fk_Booking_id == bookingId && bookingRoom.Fk_Room_Id == roomId {
+			store.data.bookingRooms[i].Amount = amount
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (store *MemoryStore) AddBookingRoomTypes(ctx context.Context, bookingRoomTypes []T_Booking_Room_Types) error {
+	defer store.lock()()
+	for _, bookingRoomType := range bookingRoomTypes {
+		bookingRoomType.Id = store.data.nextId()
+		store.data.bookingRoomTypes = append(store.data.bookingRoomTypes, bookingRoomType)
+	}
+	return nil
+}
+
+func (store *MemoryStore) ListBookedRoomTypes(ctx context.Context, bookingIds []uint) ([]BookedRoomType, error) {
+	defer store.lock()()
+	var roomTypes []BookedRoomType
+	for _, bookingRoomType := range store.data.bookingRoomTypes {
+		if !slices.Contains(bookingIds, bookingRoomType.Fk_Booking_Id) {
+			continue
+		}
+		for _, roomType := range store.data.roomTypes {
+			if roomType.Id == bookingRoomType.Fk_Room_Type_Id {
+				roomTypes = append(roomTypes, BookedRoomType{
+					Fk_Booking_Id: bookingRoomType.Fk_Booking_Id,
+					Quantity:      bookingRoomType.Quantity,
+					Adults:        bookingRoomType.Adults,
+					Children:      bookingRoomType.Children,
+					Amount:        bookingRoomType.Amount,
+					T_Room_Types:  roomType,
+				})
+			}
+		}
+	}
+	return roomTypes, nil
+}
+
+func (store *MemoryStore) UpdateBookingRoomTypeAmount(ctx context.Context, bookingId, roomTypeId uint, amount float64) error {
+	defer store.lock()()
+	for i, bookingRoomType := range store.data.bookingRoomTypes {
+		if bookingRoomType.Fk_Booking_Id == bookingId && bookingRoomType.Fk_Room_Type_Id == roomTypeId {
+			store.data.bookingRoomTypes[i].Amount = amount
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (store *MemoryStore) ListRoomTypeReservations(ctx context.Context, roomTypeId uint, start, end time.Time, exceptBookingId uint) ([]RoomTypeReservation, error) {
+	defer store.lock()()
+	var reservations []RoomTypeReservation
+	for _, bookingRoomType := range store.data.bookingRoomTypes {
+		if bookingRoomType.Fk_Room_Type_Id != roomTypeId || bookingRoomType.Fk_Booking_Id == exceptBookingId {
+			continue
+		}
+		for _, booking := range store.data.bookings {
+			if booking.Id == bookingRoomType.Fk_Booking_Id && reserves(booking, start, end) {
+				reservations = append(reservations, RoomTypeReservation{
+					Start:    booking.Start_Date,
+					End:      booking.End_Date,
+					Quantity: bookingRoomType.Quantity,
+				})
+			}
+		}
+	}
+	return reservations, nil
+}
+
+func (store *MemoryStore) CreateBookingDeposit(ctx context.Context, deposit *T_Booking_Deposits) error {
+	defer store.lock()()
+	deposit.ID = store.data.nextId()
+	store.data.bookingDeposits = append(store.data.bookingDeposits, *deposit)
+	return nil
+}
+
+func (store *MemoryStore) ListBookingDeposits(ctx context.Context, bookingIds []uint) ([]T_Booking_Deposits, error) {
+	defer store.lock()()
+	var deposits []T_Booking_Deposits
+	for _, deposit := range store.data.bookingDeposits {
+		if slices.Contains(bookingIds, deposit.Fk_Booking_ID) {
+			deposits = append(deposits, deposit)
+		}
+	}
+	return deposits, nil
+}
+
+func (store *MemoryStore) SetBookingLineItems(ctx context.Context, bookingId uint, items []T_Booking_Line_Items) error {
+	defer store.lock()()
+	store.data.bookingLineItems = slices.DeleteFunc(store.data.bookingLineItems, func(item T_Booking_Line_Items) bool {
+		return item.Fk_Booking_Id == bookingId
+	})
+	for i := range items {
+		items[i].Id = store.data.nextId()
+		items[i].Fk_Booking_Id = bookingId
+		store.data.bookingLineItems = append(store.data.bookingLineItems, items[i])
+	}
+	return nil
+}
+
+func (store *MemoryStore) ListBookingLineItems(ctx context.Context, bookingIds []uint) ([]T_Booking_Line_Items, error) {
+	defer store.lock()()
+	var items []T_Booking_Line_Items
+	for _, item := range store.data.bookingLineItems {
+		if slices.Contains(bookingIds, item.Fk_Booking_Id) {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+func (store *MemoryStore) AddBookingStatusHistory(ctx context.Context, history *T_Booking_Status_Histories) error {
+	defer store.lock()()
+	history.Id = store.data.nextId()
+	store.data.bookingHistories = append(store.data.bookingHistories, *history)
+	return nil
+}
+
+func (store *MemoryStore) ListBookingStatusHistory(ctx context.Context, bookingId uint) ([]T_Booking_Status_Histories, error) {
+	defer store.lock()()
+	var histories []T_Booking_Status_Histories
+	for _, history := range store.data.bookingHistories {
+		if history.Fk_Booking_Id == bookingId {
+			histories = append(histories, history)
+		}
+	}
+	slices.SortStableFunc(histories, func(a, b T_Booking_Status_Histories) int {
+		return a.Create_At.Compare(b.Create_At)
+	})
+	return histories, nil
+}
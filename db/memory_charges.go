@@ -0,0 +1,40 @@
+package db
+
+import (
+	"context"
+	"slices"
+)
+
+func (store *MemoryStore) CreatePropertyCharge(ctx context.Context, charge *T_Property_Charges) error {
+	defer store.lock()()
+	charge.Id = store.data.nextId()
+	store.data.propertyCharges = append(store.data.propertyCharges, *charge)
+	return nil
+}
+
+func (store *MemoryStore) ListPropertyCharges(ctx context.Context, propertyId uint) ([]T_Property_Charges, error) {
+	defer store.lock()()
+	var charges []T_Property_Charges
+	for _, charge := range store.data.propertyCharges {
+		if charge.Fk_Property_Id == propertyId {
+			charges = append(charges, charge)
+		}
+	}
+	return charges, nil
+}
+
+func (store *MemoryStore) DeletePropertyCharge(ctx context.Context, id uint) error {
+	defer store.lock()()
+	for i, charge := range store.data.propertyCharges {
+		if charge.Id == id {
+			store.data.propertyCharges = slices.Delete(store.data.propertyCharges, i, i+1)
+			for j, item := range store.data.bookingLineItems {
+				if item.Fk_Property_Charge_Id != nil && *item.Fk_Property_Charge_Id == id {
+					store.data.bookingLineItems[j].Fk_Property_Charge_Id = nil
+				}
+			}
+			return nil
+		}
+	}
+	return ErrNotFound
+}
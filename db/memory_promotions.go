@@ -0,0 +1,110 @@
+package db
+
+import (
+	"context"
+	"slices"
+)
+
+func (store *MemoryStore) CreatePromotion(ctx context.Context, promotion *T_Promotions) error {
+	defer store.lock()()
+	promotion.Id = store.data.nextId()
+	store.data.promotions = append(store.data.promotions, *promotion)
+	return nil
+}
+
+func (store *MemoryStore) AddPromotionTargets(ctx context.Context, targets []T_Promotion_Targets) error {
+	defer store.lock()()
+	for i := range targets {
+		targets[i].Id = store.data.nextId()
+		store.data.promotionTargets = append(store.data.promotionTargets, targets[i])
+	}
+	return nil
+}
+
+func (store *MemoryStore) GetPromotion(ctx context.Context, id uint) (T_Promotions, error) {
+	defer store.lock()()
+	for _, promotion := range store.data.promotions {
+		if promotion.Id == id {
+			return promotion, nil
+		}
+	}
+	return T_Promotions{}, ErrNotFound
+}
+
+// LockPromotion needs no lock of its own, transactions are serialized.
+func (store *MemoryStore) LockPromotion(ctx context.Context, id uint) (T_Promotions, error) {
+	return store.GetPromotion(ctx, id)
+}
+
+func (store *MemoryStore) GetPromotionByCode(ctx context.Context, code string) (T_Promotions, error) {
+	defer store.lock()()
+	for _, promotion := range store.data.promotions {
+		if promotion.Code == code {
+			return promotion, nil
+		}
+	}
+	return T_Promotions{}, ErrNotFound
+}
+
+// LockPromotionByCode needs no lock of its own, transactions are serialized.
+func (store *MemoryStore) LockPromotionByCode(ctx context.Context, code string) (T_Promotions, error) {
+	return store.GetPromotionByCode(ctx, code)
+}
+
+func (store *MemoryStore) ListPromotionsByIds(ctx context.Context, ids []uint) ([]T_Promotions, error) {
+	defer store.lock()()
+	var promotions []T_Promotions
+	for _, promotion := range store.data.promotions {
+		if slices.Contains(ids, promotion.Id) {
+			promotions = append(promotions, promotion)
+		}
+	}
+	return promotions, nil
+}
+
+func (store *MemoryStore) ListPromotionsByAgent(ctx context.Context, agentId uint) ([]T_Promotions, error) {
+	defer store.lock()()
+	var promotions []T_Promotions
+	for _, promotion := range store.data.promotions {
+		if promotion.Fk_Agent_Id == agentId {
+			promotions = append(promotions, promotion)
+		}
+	}
+	slices.Reverse(promotions)
+	return promotions, nil
+}
+
+func (store *MemoryStore) ListPromotionTargets(ctx context.Context, promotionIds []uint) ([]T_Promotion_Targets, error) {
+	defer store.lock()()
+	var targets []T_Promotion_Targets
+	for _, target := range store.data.promotionTargets {
+		if slices.Contains(promotionIds, target.Fk_Promotion_Id) {
+			targets = append(targets, target)
+		}
+	}
+	return targets, nil
+}
+
+func (store *MemoryStore) RedeemPromotion(ctx context.Context, id uint) error {
+	defer store.lock()()
+	for i := range store.data.promotions {
+		if store.data.promotions[i].Id == id {
+			store.data.promotions[i].Redemptions++
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (store *MemoryStore) ReleasePromotion(ctx context.Context, id uint) error {
+	defer store.lock()()
+	for i := range store.data.promotions {
+		if store.data.promotions[i].Id == id {
+			if store.data.promotions[i].Redemptions > 0 {
+				store.data.promotions[i].Redemptions--
+			}
+			return nil
+		}
+	}
+	return ErrNotFound
+}
@@ -0,0 +1,120 @@
+package db
+
+import (
+	"context"
+	"slices"
+)
+
+func (store *MemoryStore) GetProperty(ctx context.Context, id uint) (T_Properties, error) {
+	defer store.lock()()
+	for _, property := range store.data.properties {
+		if property.Id == id {
+			return property, nil
+		}
+	}
+	return T_Properties{}, ErrNotFound
+}
+
+func (store *MemoryStore) ListPropertiesByIds(ctx context.Context, ids []uint) ([]T_Properties, error) {
+	defer store.lock()()
+	var properties []T_Properties
+	for _, property := range store.data.properties {
+		if slices.Contains(ids, property.Id) {
+			properties = append(properties, property)
+		}
+	}
+	return properties, nil
+}
+
+func (store *MemoryStore) ListPropertiesByAgent(ctx context.Context, agentId uint, excludeStatus string) ([]T_Properties, error) {
+	defer store.lock()()
+	var properties []T_Properties
+	for _, property := range store.data.properties {
+		if property.Fk_Argent_Id != agentId {
+			continue
+		}
+		if excludeStatus != "" && property.Status == excludeStatus {
+			continue
+		}
+		properties = append(properties, property)
+	}
+	return properties, nil
+}
+
+func (store *MemoryStore) CountPropertiesByAgent(ctx context.Context, agentId uint) (int64, error) {
+	defer store.lock()()
+	var count int64
+	for _, property := range store.data.properties {
+		if property.Fk_Argent_Id == agentId {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (store *MemoryStore) CreateProperty(ctx context.Context, property *T_Properties) error {
+	defer store.lock()()
+	property.Id = store.data.nextId()
+	store.data.properties = append(store.data.properties, *property)
+	return nil
+}
+
+func (store *MemoryStore) UpdatePropertyStatus(ctx context.Context, id uint, status string) error {
+	defer store.lock()()
+	for i := range store.data.properties {
+		if store.data.properties[i].Id == id {
+			store.data.properties[i].Status = status
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (store *MemoryStore) AddPropertyImages(ctx context.Context, propertyId uint, urls []string) error {
+	defer store.lock()()
+	for _, url := range urls {
+		store.data.propertyImages = append(store.data.propertyImages, T_Property_Images{
+			Id:             store.data.nextId(),
+			Url:            url,
+			Fk_Property_Id: propertyId,
+		})
+	}
+	return nil
+}
+
+func (store *MemoryStore) ListPropertyImages(ctx context.Context, propertyIds []uint) ([]T_Property_Images, error) {
+	defer store.lock()()
+	var images []T_Property_Images
+	for _, image := range store.data.propertyImages {
+		if slices.Contains(propertyIds, image.Fk_Property_Id) {
+			images = append(images, image)
+		}
+	}
+	return images, nil
+}
+
+func (store *MemoryStore) AddPropertyAmenities(ctx context.Context, propertyId uint, amenityIds []uint) error {
+	defer store.lock()()
+	for _, amenityId := range amenityIds {
+		store.data.propertyAmenities = append(store.data.propertyAmenities, T_Property_Amenities{
+			Id:             store.data.nextId(),
+			Fk_Property_Id: propertyId,
+			Fk_Amenity_Id:  amenityId,
+		})
+	}
+	return nil
+}
+
+func (store *MemoryStore) ListPropertyAmenities(ctx context.Context, propertyIds []uint) ([]PropertyAmenity, error) {
+	defer store.lock()()
+	var amenities []PropertyAmenity
+	for _, propertyAmenity := range store.data.propertyAmenities {
+		if !slices.Contains(propertyIds, propertyAmenity.Fk_Property_Id) {
+			continue
+		}
+		if amenity, ok := store.data.amenity(propertyAmenity.Fk_Amenity_Id); ok && !amenity.Is_Deleted {
+			amenities = append(amenities, PropertyAmenity{Fk_Property_Id: propertyAmenity.Fk_Property_Id, T_Amenities: amenity})
+		}
+	}
+	return amenities, nil
+}
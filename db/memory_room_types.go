@@ -0,0 +1,99 @@
+package db
+
+import (
+	"context"
+	"slices"
+)
+
+func (store *MemoryStore) GetRoomType(ctx context.Context, id uint) (T_Room_Types, error) {
+	defer store.lock()()
+	for _, roomType := range store.data.roomTypes {
+		if roomType.Id == id {
+			return roomType, nil
+		}
+	}
+	return T_Room_Types{}, ErrNotFound
+}
+
+// LockRoomType needs no lock of its own, transactions are serialized.
+func (store *MemoryStore) LockRoomType(ctx context.Context, id uint) (T_Room_Types, error) {
+	return store.GetRoomType(ctx, id)
+}
+
+func (store *MemoryStore) ListRoomTypesByProperties(ctx context.Context, propertyIds []uint) ([]T_Room_Types, error) {
+	defer store.lock()()
+	var roomTypes []T_Room_Types
+	for _, roomType := range store.data.roomTypes {
+		if slices.Contains(propertyIds, roomType.Fk_Property_Id) {
+			roomTypes = append(roomTypes, roomType)
+		}
+	}
+	return roomTypes, nil
+}
+
+func (store *MemoryStore) CreateRoomType(ctx context.Context, roomType *T_Room_Types) error {
+	defer store.lock()()
+	roomType.Id = store.data.nextId()
+	store.data.roomTypes = append(store.data.roomTypes, *roomType)
+	return nil
+}
+
+func (store *MemoryStore) ListRoomTypeUnits(ctx context.Context, roomTypeIds []uint) ([]T_Rooms, error) {
+	defer store.lock()()
+	var rooms []T_Rooms
+	for _, room := range store.data.rooms {
+		if room.Fk_Room_Type_Id != nil && slices.Contains(roomTypeIds, *room.Fk_Room_Type_Id) {
+			rooms = append(rooms, room)
+		}
+	}
+	return rooms, nil
+}
+
+func (store *MemoryStore) AddRoomTypeImages(ctx context.Context, roomTypeId uint, urls []string) error {
+	defer store.lock()()
+	for _, url := range urls {
+		store.data.roomTypeImages = append(store.data.roomTypeImages, T_Room_Type_Images{
+			Id:              store.data.nextId(),
+			Url:             url,
+			Fk_Room_Type_Id: roomTypeId,
+		})
+	}
+	return nil
+}
+
+func (store *MemoryStore) ListRoomTypeImages(ctx context.Context, roomTypeIds []uint) ([]T_Room_Type_Images, error) {
+	defer store.lock()()
+	var images []T_Room_Type_Images
+	for _, image := range store.data.roomTypeImages {
+		if slices.Contains(roomTypeIds, image.Fk_Room_Type_Id) {
+			images = append(images, image)
+		}
+	}
+	return images, nil
+}
+
+func (store *MemoryStore) AddRoomTypeAmenities(ctx context.Context, roomTypeId uint, amenityIds []uint) error {
+	defer store.lock()()
+	for _, amenityId := range amenityIds {
+		store.data.roomTypeAmenities = append(store.data.roomTypeAmenities, T_Room_Type_Amenities{
+			Id:              store.data.nextId(),
+			Fk_Room_Type_Id: roomTypeId,
+			Fk_Amenity_Id:   amenityId,
+		})
+	}
+	return nil
+}
+
+func (store *MemoryStore) ListRoomTypeAmenities(ctx context.Context, roomTypeIds []uint) ([]RoomTypeAmenity, error) {
+	defer store.lock()()
+	var amenities []RoomTypeAmenity
+	for _, roomTypeAmenity := range store.data.roomTypeAmenities {
+		if !slices.Contains(roomTypeIds, roomTypeAmenity.Fk_Room_Type_Id) {
+			continue
+		}
+		if amenity, ok := store.data.amenity(roomTypeAmenity.Fk_Amenity_Id); ok && !amenity.Is_Deleted {
+			amenities = append(amenities, RoomTypeAmenity{Fk_Room_Type_Id: roomTypeAmenity.Fk_Room_Type_Id, T_Amenities: amenity})
+		}
+	}
+	return amenities, nil
+}
@@ -0,0 +1,98 @@
+package db
+
+import (
+	"context"
+	"slices"
+)
+
+func (store *MemoryStore) GetRoom(ctx context.Context, id uint) (T_Rooms, error) {
+	defer store.lock()()
+	for _, room := range store.data.rooms {
+		if room.Id == id {
+			return room, nil
+		}
+	}
+	return T_Rooms{}, ErrNotFound
+}
+
+func (store *MemoryStore) ListRoomsByProperties(ctx context.Context, propertyIds []uint, excludeStatus string) ([]T_Rooms, error) {
+	defer store.lock()()
+	var rooms []T_Rooms
+	for _, room := range store.data.rooms {
+		if !slices.Contains(propertyIds, room.Fk_Property_Id) {
+			continue
+		}
+		if excludeStatus != "" && room.Status == excludeStatus {
+			continue
+		}
+		rooms = append(rooms, room)
+	}
+	return rooms, nil
+}
+
+func (store *MemoryStore) CreateRoom(ctx context.Context, room *T_Rooms) error {
+	defer store.lock()()
+	room.Id = store.data.nextId()
+	store.data.rooms = append(store.data.rooms, *room)
+	return nil
+}
+
+func (store *MemoryStore) UpdateRoomStatus(ctx context.Context, id uint, status string) error {
+	defer store.lock()()
+	for i := range store.data.rooms {
+		if store.data.rooms[i].Id == id {
+			store.data.rooms[i].Status = status
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (store *MemoryStore) AddRoomImages(ctx context.Context, roomId uint, urls []string) error {
+	defer store.lock()()
+	for _, url := range urls {
+		store.data.roomImages = append(store.data.roomImages, T_Room_Images{
+			Id:         store.data.nextId(),
+			Url:        url,
+			Fk_Room_Id: roomId,
+		})
+	}
+	return nil
+}
+
+func (store *MemoryStore) ListRoomImages(ctx context.Context, roomIds []uint) ([]T_Room_Images, error) {
+	defer store.lock()()
+	var images []T_Room_Images
+	for _, image := range store.data.roomImages {
+		if slices.Contains(roomIds, image.Fk_Room_Id) {
+			images = append(images, image)
+		}
+	}
+	return images, nil
+}
+
+func (store *MemoryStore) AddRoomAmenities(ctx context.Context, roomId uint, amenityIds []uint) error {
+	defer store.lock()()
+	for _, amenityId := range amenityIds {
+		store.data.roomAmenities = append(store.data.roomAmenities, T_Room_Amenities{
+			Id:            store.data.nextId(),
+			Fk_Room_Id:    roomId,
+			Fk_Amenity_Id: amenityId,
+		})
+	}
+	return nil
+}
+
+func (store *MemoryStore) ListRoomAmenities(ctx context.Context, roomIds []uint) ([]RoomAmenity, error) {
+	defer store.lock()()
+	var amenities []RoomAmenity
+	for _, roomAmenity := range store.data.roomAmenities {
+		if !slices.Contains(roomIds, roomAmenity.Fk_Room_Id) {
+			continue
+		}
+		if amenity, ok := store.data.amenity(roomAmenity.Fk_Amenity_Id); ok && !amenity.Is_Deleted {
+			amenities = append(amenities, RoomAmenity{Fk_Room_Id: roomAmenity.Fk_Room_Id, T_Amenities: amenity})
+		}
+	}
+	return amenities, nil
+}
@@ -0,0 +1,45 @@
+package db
+
+import (
+	"context"
+)
+
+func (store *MemoryStore) GetUser(ctx context.Context, id uint) (T_Users, error) {
+	defer store.lock()()
+	for _, user := range store.data.users {
+		if user.Id == id {
+			return user, nil
+		}
+	}
+	return T_Users{}, ErrNotFound
+}
+
+func (store *MemoryStore) CreateUser(ctx context.Context, user *T_Users) error {
+	defer store.lock()()
+	user.Id = store.data.nextId()
+	store.data.users = append(store.data.users, *user)
+	return nil
+}
+
+func (store *MemoryStore) AddAgentStaff(ctx context.Context, agentStaff *T_Agent_Staffs) error {
+	defer store.lock()()
+	agentStaff.Id = store.data.nextId()
+	store.data.agentStaffs = append(store.data.agentStaffs, *agentStaff)
+	return nil
+}
+
+func (store *MemoryStore) ListStaffByAgent(ctx context.Context, agentId uint) ([]T_Users, error) {
+	defer store.lock()()
+	var staffs []T_Users
+	for _, agentStaff := range store.data.agentStaffs {
+		if agentStaff.Agent_Id != agentId {
+			continue
+		}
+		for _, user := range store.data.users {
+			if user.Id == agentStaff.Staff_Id {
+				staffs = append(staffs, user)
+			}
+		}
+	}
+	return staffs, nil
+}
@@ -0,0 +1,38 @@
+package db
+
+import (
+	"context"
+	"slices"
+)
+
+func (store *MemoryStore) CreateStayRestriction(ctx context.Context, restriction *T_Stay_Restrictions) error {
+	defer store.lock()()
+	restriction.Id = store.data.nextId()
+	store.data.stayRestrictions = append(store.data.stayRestrictions, *restriction)
+	return nil
+}
+
+func (store *MemoryStore) ListStayRestrictions(ctx context.Context, propertyId uint) ([]T_Stay_Restrictions, error) {
+	defer store.lock()()
+	var restrictions []T_Stay_Restrictions
+	for _, restriction := range store.data.stayRestrictions {
+		if restriction.Fk_Property_Id == propertyId {
+			restrictions = append(restrictions, restriction)
+		}
+	}
+	slices.SortStableFunc(restrictions, func(a, b T_Stay_Restrictions) int {
+		return a.Start_Date.Compare(b.Start_Date)
+	})
+	return restrictions, nil
+}
+
+func (store *MemoryStore) DeleteStayRestriction(ctx context.Context, id uint) error {
+	defer store.lock()()
+	for i, restriction := range store.data.stayRestrictions {
+		if restriction.Id == id {
+			store.data.stayRestrictions = slices.Delete(store.data.stayRestrictions, i, i+1)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
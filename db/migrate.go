@@ -0,0 +1,146 @@
+package db
+
+import (
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/pgx/v5"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+//go:embed migration/*.sql
+var migrationFiles embed.FS
+
+// MigrationStatus compares the schema version of a database with the newest
+// migration shipped in this binary.
+type MigrationStatus struct {
+	Current uint
+	Latest  uint
+	Dirty   bool
+}
+
+// UpToDate reports whether every migration has been applied cleanly.
+func (status MigrationStatus) UpToDate() bool {
+	return !status.Dirty && status.Current == status.Latest
+}
+
+// Migrator applies the versioned SQL migrations in db/migration.
+type Migrator struct {
+	migrate *migrate.Migrate
+	latest  uint
+}
+
+// NewMigrator opens its own connection to dbSource, so closing the migrator
+// never affects the connection pool used by the server.
+func NewMigrator(dbSource string) (*Migrator, error) {
+	sourceDriver, err := iofs.New(migrationFiles, "migration")
+	if err != nil {
+		return nil, fmt.Errorf("cannot load migrations: %w", err)
+	}
+	latest, err := latestVersion(sourceDriver)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load migrations: %w", err)
+	}
+
+	conn, err := sql.Open("pgx", dbSource)
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to db: %w", err)
+	}
+	databaseDriver, err := pgx.WithInstance(conn, &pgx.Config{})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("cannot connect to db: %w", err)
+	}
+
+	m, err := migrate.NewWithInstance("iofs", sourceDriver, "pgx", databaseDriver)
+	if err != nil {
+		databaseDriver.Close()
+		return nil, err
+	}
+	return &Migrator{migrate: m, latest: latest}, nil
+}
+
+// Up applies every pending migration.
+func (migrator *Migrator) Up() error {
+	if err := migrator.migrate.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return err
+	}
+	return nil
+}
+
+// Down rolls back the given number of applied migrations.
+func (migrator *Migrator) Down(steps int) error {
+	if err := migrator.migrate.Steps(-steps); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return err
+	}
+	return nil
+}
+
+func (migrator *Migrator) Status() (MigrationStatus, error) {
+	status := MigrationStatus{Latest: migrator.latest}
+	version, dirty, err := migrator.migrate.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return status, err
+	}
+	status.Current = version
+	status.Dirty = dirty
+	return status, nil
+}
+
+// Close releases the migrator's database connection.
+func (migrator *Migrator) Close() error {
+	sourceErr, databaseErr := migrator.migrate.Close()
+	return errors.Join(sourceErr, databaseErr)
+}
+
+// CheckSchema returns an error unless the database at dbSource has every
+// migration in this binary applied. The server calls it before serving so it
+// never runs against a schema that is behind.
+func CheckSchema(dbSource string) error {
+	migrator, err := NewMigrator(dbSource)
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+
+	status, err := migrator.Status()
+	if err != nil {
+		return err
+	}
+	if status.Dirty {
+		return fmt.Errorf("database schema is dirty at version %d, fix it and run \"migrate up\"", status.Current)
+	}
+	if status.Current < status.Latest {
+		return fmt.Errorf("database schema is at version %d but version %d is required, run \"migrate up\"", status.Current, status.Latest)
+	}
+	if status.Current > status.Latest {
+		return fmt.Errorf("database schema version %d is newer than this binary supports (%d)", status.Current, status.Latest)
+	}
+	return nil
+}
+
+func latestVersion(driver source.Driver) (uint, error) {
+	version, err := driver.First()
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	for {
+		next, err := driver.Next(version)
+		if errors.Is(err, fs.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		version = next
+	}
+}
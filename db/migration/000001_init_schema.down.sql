@@ -0,0 +1,5 @@
+-- The baseline may have adopted a database that held data before migrations
+-- existed, so rolling it back must not drop any tables. Only the version is
+-- reset; running "migrate up" again is safe because every table is created
+-- only when missing. Drop the tables by hand if the schema really has to go.
+SELECT 1;
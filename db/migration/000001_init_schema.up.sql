@@ -0,0 +1,164 @@
+-- Tables are created only when missing so databases that were set up by
+-- hand before migrations existed can be brought under version control by
+-- running "migrate up" once.
+
+CREATE TABLE IF NOT EXISTS t_users (
+  id bigserial PRIMARY KEY,
+  first_name varchar(100) NOT NULL DEFAULT '',
+  last_name varchar(100) NOT NULL DEFAULT '',
+  email varchar(100) UNIQUE,
+  phone_number varchar(15) NOT NULL DEFAULT '',
+  role varchar(50) NOT NULL DEFAULT '',
+  avatar varchar(255) NOT NULL DEFAULT '',
+  status varchar(50) NOT NULL DEFAULT '',
+  password varchar(255) NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS t_argents (
+  id bigserial PRIMARY KEY,
+  fk_user_id bigint NOT NULL REFERENCES t_users (id),
+  identity_number varchar(15) NOT NULL DEFAULT '',
+  front_identity_card varchar(15) NOT NULL DEFAULT '',
+  back_identity_card varchar(15) NOT NULL DEFAULT '',
+  selfie_img varchar(15) NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS t_agent_staffs (
+  id bigserial PRIMARY KEY,
+  agent_id bigint NOT NULL,
+  staff_id bigint NOT NULL REFERENCES t_users (id)
+);
+CREATE INDEX IF NOT EXISTS t_agent_staffs_agent_id_idx ON t_agent_staffs (agent_id);
+
+CREATE TABLE IF NOT EXISTS t_provinces (
+  id bigserial PRIMARY KEY,
+  province_name varchar(100) NOT NULL DEFAULT '',
+  province_type varchar(50) NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS t_districts (
+  id bigserial PRIMARY KEY,
+  district_name varchar(100) NOT NULL DEFAULT '',
+  district_type varchar(50) NOT NULL DEFAULT '',
+  latitude double precision NOT NULL DEFAULT 0,
+  longitude double precision NOT NULL DEFAULT 0,
+  province_id bigint NOT NULL REFERENCES t_provinces (id)
+);
+
+CREATE TABLE IF NOT EXISTS t_wards (
+  id bigserial PRIMARY KEY,
+  ward_name varchar(100) NOT NULL DEFAULT '',
+  ward_type varchar(50) NOT NULL DEFAULT '',
+  fk_district_id bigint NOT NULL REFERENCES t_districts (id)
+);
+
+CREATE TABLE IF NOT EXISTS t_amenities (
+  id bigserial PRIMARY KEY,
+  name varchar(100) NOT NULL DEFAULT '',
+  type varchar(50) NOT NULL DEFAULT '',
+  is_deleted boolean NOT NULL DEFAULT false
+);
+
+CREATE TABLE IF NOT EXISTS t_properties (
+  id bigserial PRIMARY KEY,
+  name varchar(100) NOT NULL DEFAULT '',
+  deposit_percent double precision NOT NULL DEFAULT 0,
+  fk_ward_id bigint NOT NULL,
+  fk_district_id bigint NOT NULL,
+  fk_province_id bigint NOT NULL,
+  description text,
+  longitude double precision,
+  latitude double precision,
+  address varchar(255) NOT NULL DEFAULT '',
+  fk_argent_id bigint NOT NULL,
+  status varchar(50) NOT NULL DEFAULT '',
+  type varchar(50) NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS t_properties_fk_argent_id_idx ON t_properties (fk_argent_id);
+
+CREATE TABLE IF NOT EXISTS t_property_images (
+  id bigserial PRIMARY KEY,
+  url varchar(255) NOT NULL DEFAULT '',
+  fk_property_id bigint NOT NULL REFERENCES t_properties (id)
+);
+CREATE INDEX IF NOT EXISTS t_property_images_fk_property_id_idx ON t_property_images (fk_property_id);
+
+CREATE TABLE IF NOT EXISTS t_property_amenities (
+  id bigserial PRIMARY KEY,
+  fk_property_id bigint NOT NULL REFERENCES t_properties (id),
+  fk_amenity_id bigint NOT NULL REFERENCES t_amenities (id)
+);
+CREATE INDEX IF NOT EXISTS t_property_amenities_fk_property_id_idx ON t_property_amenities (fk_property_id);
+
+CREATE TABLE IF NOT EXISTS t_rooms (
+  id bigserial PRIMARY KEY,
+  fk_property_id bigint NOT NULL REFERENCES t_properties (id),
+  name varchar(100) NOT NULL DEFAULT '',
+  status varchar(50) NOT NULL DEFAULT '',
+  price bigint NOT NULL
+);
+CREATE INDEX IF NOT EXISTS t_rooms_fk_property_id_idx ON t_rooms (fk_property_id);
+
+CREATE TABLE IF NOT EXISTS t_room_images (
+  id bigserial PRIMARY KEY,
+  url varchar(255) NOT NULL DEFAULT '',
+  fk_room_id bigint NOT NULL REFERENCES t_rooms (id)
+);
+CREATE INDEX IF NOT EXISTS t_room_images_fk_room_id_idx ON t_room_images (fk_room_id);
+
+CREATE TABLE IF NOT EXISTS t_room_amenities (
+  id bigserial PRIMARY KEY,
+  fk_room_id bigint NOT NULL REFERENCES t_rooms (id),
+  fk_amenity_id bigint NOT NULL REFERENCES t_amenities (id)
+);
+CREATE INDEX IF NOT EXISTS t_room_amenities_fk_room_id_idx ON t_room_amenities (fk_room_id);
+
+CREATE TABLE IF NOT EXISTS t_bookings (
+  id bigserial PRIMARY KEY,
+  fk_user_id bigint NOT NULL,
+  status varchar(50) NOT NULL DEFAULT '',
+  start_date timestamptz NOT NULL,
+  end_date timestamptz NOT NULL,
+  create_at timestamptz NOT NULL DEFAULT now(),
+  total_price double precision NOT NULL,
+  fk_property_id bigint NOT NULL REFERENCES t_properties (id)
+);
+CREATE INDEX IF NOT EXISTS t_bookings_fk_user_id_idx ON t_bookings (fk_user_id);
+CREATE INDEX IF NOT EXISTS t_bookings_fk_property_id_idx ON t_bookings (fk_property_id);
+
+CREATE TABLE IF NOT EXISTS t_booking_rooms (
+  id bigserial PRIMARY KEY,
+  fk_room_id bigint NOT NULL REFERENCES t_rooms (id),
+  fk_booking_id bigint NOT NULL REFERENCES t_bookings (id)
+);
+CREATE INDEX IF NOT EXISTS t_booking_rooms_fk_booking_id_idx ON t_booking_rooms (fk_booking_id);
+CREATE INDEX IF NOT EXISTS t_booking_rooms_fk_room_id_idx ON t_booking_rooms (fk_room_id);
+
+CREATE TABLE IF NOT EXISTS t_booking_deposits (
+  id bigserial PRIMARY KEY,
+  fk_booking_id bigint NOT NULL REFERENCES t_bookings (id),
+  image varchar(255),
+  deposit double precision NOT NULL
+);
+CREATE INDEX IF NOT EXISTS t_booking_deposits_fk_booking_id_idx ON t_booking_deposits (fk_booking_id);
+
+CREATE TABLE IF NOT EXISTS t_booking_status_histories (
+  id bigserial PRIMARY KEY,
+  fk_booking_id bigint NOT NULL REFERENCES t_bookings (id),
+  from_status varchar(50) NOT NULL DEFAULT '',
+  to_status varchar(50) NOT NULL,
+  create_at timestamptz NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS t_booking_status_histories_fk_booking_id_idx ON t_booking_status_histories (fk_booking_id);
+
+CREATE TABLE IF NOT EXISTS t_banks (
+  id bigserial PRIMARY KEY,
+  bank_name text NOT NULL DEFAULT '',
+  account_number text NOT NULL DEFAULT '',
+  qr_code text,
+  fk_argent_id bigint NOT NULL,
+  is_default boolean NOT NULL DEFAULT false,
+  create_at timestamptz NOT NULL DEFAULT now(),
+  account_name text NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS t_banks_fk_argent_id_idx ON t_banks (fk_argent_id);
@@ -0,0 +1,10 @@
+ALTER TABLE t_bookings
+  DROP COLUMN IF EXISTS late_check_out,
+  DROP COLUMN IF EXISTS early_check_in,
+  DROP COLUMN IF EXISTS nights;
+
+ALTER TABLE t_properties
+  DROP COLUMN IF EXISTS late_check_out_time,
+  DROP COLUMN IF EXISTS early_check_in_time,
+  DROP COLUMN IF EXISTS check_out_time,
+  DROP COLUMN IF EXISTS check_in_time;
@@ -0,0 +1,21 @@
+-- Check-in and check-out times are "HH:MM" in the local time of the
+-- property. An empty check_in_time or check_out_time falls back to the
+-- server default; a NULL early or late time means the option is not offered.
+ALTER TABLE t_properties
+  ADD COLUMN IF NOT EXISTS check_in_time varchar(5) NOT NULL DEFAULT '',
+  ADD COLUMN IF NOT EXISTS check_out_time varchar(5) NOT NULL DEFAULT '',
+  ADD COLUMN IF NOT EXISTS early_check_in_time varchar(5),
+  ADD COLUMN IF NOT EXISTS late_check_out_time varchar(5);
+
+ALTER TABLE t_bookings
+  ADD COLUMN IF NOT EXISTS nights integer NOT NULL DEFAULT 0,
+  ADD COLUMN IF NOT EXISTS early_check_in boolean NOT NULL DEFAULT false,
+  ADD COLUMN IF NOT EXISTS late_check_out boolean NOT NULL DEFAULT false;
+
+-- Bookings made before stays were counted in nights were billed by the
+-- length of the stay in days. That length, rounded, is their number of
+-- nights, which keeps the backfill independent of any time zone.
+UPDATE t_bookings
+SET nights = GREATEST(1,
+  ROUND(EXTRACT(EPOCH FROM end_date - start_date) / 86400)::integer)
+WHERE nights = 0;
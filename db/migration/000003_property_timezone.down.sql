@@ -0,0 +1,2 @@
+ALTER TABLE t_properties
+  DROP COLUMN IF EXISTS timezone;
@@ -0,0 +1,4 @@
+-- IANA time zone of the property, e.g. Asia/Ho_Chi_Minh. Empty uses the
+-- server default. Stay dates are local to it; instants are stored in UTC.
+ALTER TABLE t_properties
+  ADD COLUMN IF NOT EXISTS timezone varchar(64) NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+ALTER TABLE t_booking_status_histories
+  DROP COLUMN IF EXISTS note;
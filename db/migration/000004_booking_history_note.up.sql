@@ -0,0 +1,4 @@
+-- Describes booking changes that are not status changes, such as new dates
+-- or rooms
+ALTER TABLE t_booking_status_histories
+  ADD COLUMN IF NOT EXISTS note text NOT NULL DEFAULT '';
@@ -0,0 +1,8 @@
+ALTER TABLE t_booking_rooms
+  DROP COLUMN IF EXISTS adults,
+  DROP COLUMN IF EXISTS children;
+
+ALTER TABLE t_rooms
+  DROP COLUMN IF EXISTS max_adults,
+  DROP COLUMN IF EXISTS max_children,
+  DROP COLUMN IF EXISTS beds;
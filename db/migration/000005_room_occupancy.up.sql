@@ -0,0 +1,10 @@
+-- Rooms listed before occupancy limits were recorded are assumed to be
+-- doubles, and their bookings to have one adult per room
+ALTER TABLE t_rooms
+  ADD COLUMN IF NOT EXISTS max_adults integer NOT NULL DEFAULT 2,
+  ADD COLUMN IF NOT EXISTS max_children integer NOT NULL DEFAULT 0,
+  ADD COLUMN IF NOT EXISTS beds integer NOT NULL DEFAULT 1;
+
+ALTER TABLE t_booking_rooms
+  ADD COLUMN IF NOT EXISTS adults integer NOT NULL DEFAULT 1,
+  ADD COLUMN IF NOT EXISTS children integer NOT NULL DEFAULT 0;
@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS t_booking_room_types;
+
+ALTER TABLE t_rooms
+  DROP COLUMN IF EXISTS fk_room_type_id;
+
+DROP TABLE IF EXISTS t_room_type_amenities;
+DROP TABLE IF EXISTS t_room_type_images;
+DROP TABLE IF EXISTS t_room_types;
@@ -0,0 +1,41 @@
+CREATE TABLE IF NOT EXISTS t_room_types (
+  id bigserial PRIMARY KEY,
+  fk_property_id bigint NOT NULL REFERENCES t_properties (id),
+  name varchar(100) NOT NULL DEFAULT '',
+  status varchar(50) NOT NULL DEFAULT '',
+  price bigint NOT NULL,
+  max_adults integer NOT NULL,
+  max_children integer NOT NULL DEFAULT 0,
+  beds integer NOT NULL
+);
+CREATE INDEX IF NOT EXISTS t_room_types_fk_property_id_idx ON t_room_types (fk_property_id);
+
+CREATE TABLE IF NOT EXISTS t_room_type_images (
+  id bigserial PRIMARY KEY,
+  url varchar(255) NOT NULL DEFAULT '',
+  fk_room_type_id bigint NOT NULL REFERENCES t_room_types (id)
+);
+CREATE INDEX IF NOT EXISTS t_room_type_images_fk_room_type_id_idx ON t_room_type_images (fk_room_type_id);
+
+CREATE TABLE IF NOT EXISTS t_room_type_amenities (
+  id bigserial PRIMARY KEY,
+  fk_room_type_id bigint NOT NULL REFERENCES t_room_types (id),
+  fk_amenity_id bigint NOT NULL REFERENCES t_amenities (id)
+);
+CREATE INDEX IF NOT EXISTS t_room_type_amenities_fk_room_type_id_idx ON t_room_type_amenities (fk_room_type_id);
+
+-- Rooms without a type stay individually bookable
+ALTER TABLE t_rooms
+  ADD COLUMN IF NOT EXISTS fk_room_type_id bigint REFERENCES t_room_types (id);
+CREATE INDEX IF NOT EXISTS t_rooms_fk_room_type_id_idx ON t_rooms (fk_room_type_id);
+
+CREATE TABLE IF NOT EXISTS t_booking_room_types (
+  id bigserial PRIMARY KEY,
+  fk_booking_id bigint NOT NULL REFERENCES t_bookings (id),
+  fk_room_type_id bigint NOT NULL REFERENCES t_room_types (id),
+  quantity integer NOT NULL,
+  adults integer NOT NULL,
+  children integer NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS t_booking_room_types_fk_booking_id_idx ON t_booking_room_types (fk_booking_id);
+CREATE INDEX IF NOT EXISTS t_booking_room_types_fk_room_type_id_idx ON t_booking_room_types (fk_room_type_id);
@@ -0,0 +1,16 @@
+-- Dates are local to the property. weekdays has bit n set for the nth day
+-- of the week, Sunday first; 0 means every day.
+CREATE TABLE IF NOT EXISTS t_stay_restrictions (
+  id bigserial PRIMARY KEY,
+  fk_property_id bigint NOT NULL REFERENCES t_properties (id),
+  fk_room_id bigint REFERENCES t_rooms (id),
+  fk_room_type_id bigint REFERENCES t_room_types (id),
+  start_date date NOT NULL,
+  end_date date NOT NULL,
+  weekdays integer NOT NULL DEFAULT 0,
+  min_nights integer NOT NULL DEFAULT 0,
+  max_nights integer NOT NULL DEFAULT 0,
+  closed_to_arrival boolean NOT NULL DEFAULT false,
+  closed_to_departure boolean NOT NULL DEFAULT false
+);
+CREATE INDEX IF NOT EXISTS t_stay_restrictions_fk_property_id_idx ON t_stay_restrictions (fk_property_id);
@@ -0,0 +1,6 @@
+ALTER TABLE t_bookings
+  DROP COLUMN IF EXISTS fk_promotion_id,
+  DROP COLUMN IF EXISTS discount,
+  DROP COLUMN IF EXISTS subtotal_price;
+DROP TABLE IF EXISTS t_promotion_targets;
+DROP TABLE IF EXISTS t_promotions;
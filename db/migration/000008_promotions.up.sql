@@ -0,0 +1,35 @@
+-- Dates are local to the property. min_nights and max_redemptions are not
+-- limits when 0.
+CREATE TABLE IF NOT EXISTS t_promotions (
+  id bigserial PRIMARY KEY,
+  fk_agent_id bigint NOT NULL,
+  code varchar(32) NOT NULL,
+  name varchar(100) NOT NULL DEFAULT '',
+  type varchar(20) NOT NULL,
+  value double precision NOT NULL,
+  start_date date NOT NULL,
+  end_date date NOT NULL,
+  min_nights integer NOT NULL DEFAULT 0,
+  max_redemptions integer NOT NULL DEFAULT 0,
+  redemptions integer NOT NULL DEFAULT 0,
+  create_at timestamptz NOT NULL DEFAULT now()
+);
+CREATE UNIQUE INDEX IF NOT EXISTS t_promotions_code_idx ON t_promotions (code);
+CREATE INDEX IF NOT EXISTS t_promotions_fk_agent_id_idx ON t_promotions (fk_agent_id);
+
+-- A promotion without targets applies to every property of its agent
+CREATE TABLE IF NOT EXISTS t_promotion_targets (
+  id bigserial PRIMARY KEY,
+  fk_promotion_id bigint NOT NULL REFERENCES t_promotions (id),
+  fk_property_id bigint NOT NULL REFERENCES t_properties (id),
+  fk_room_id bigint REFERENCES t_rooms (id),
+  fk_room_type_id bigint REFERENCES t_room_types (id)
+);
+CREATE INDEX IF NOT EXISTS t_promotion_targets_fk_promotion_id_idx ON t_promotion_targets (fk_promotion_id);
+
+ALTER TABLE t_bookings
+  ADD COLUMN IF NOT EXISTS subtotal_price double precision NOT NULL DEFAULT 0,
+  ADD COLUMN IF NOT EXISTS discount double precision NOT NULL DEFAULT 0,
+  ADD COLUMN IF NOT EXISTS fk_promotion_id bigint REFERENCES t_promotions (id);
+-- Bookings made before promotions had no discount
+UPDATE t_bookings SET subtotal_price = total_price;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS t_booking_line_items;
+DROP TABLE IF EXISTS t_property_charges;
@@ -0,0 +1,27 @@
+-- Taxes and fees of a property. value is a percent for PERCENTAGE charges
+-- and an amount per room and night for PER_NIGHT charges.
+CREATE TABLE IF NOT EXISTS t_property_charges (
+  id bigserial PRIMARY KEY,
+  fk_property_id bigint NOT NULL REFERENCES t_properties (id),
+  name varchar(100) NOT NULL,
+  kind varchar(20) NOT NULL,
+  basis varchar(20) NOT NULL,
+  value double precision NOT NULL,
+  inclusive boolean NOT NULL DEFAULT false
+);
+CREATE INDEX IF NOT EXISTS t_property_charges_fk_property_id_idx ON t_property_charges (fk_property_id);
+
+-- Charges are copied onto the booking; fk_property_charge_id is cleared when
+-- the rule is deleted
+CREATE TABLE IF NOT EXISTS t_booking_line_items (
+  id bigserial PRIMARY KEY,
+  fk_booking_id bigint NOT NULL REFERENCES t_bookings (id),
+  fk_property_charge_id bigint REFERENCES t_property_charges (id) ON DELETE SET NULL,
+  name varchar(100) NOT NULL,
+  kind varchar(20) NOT NULL,
+  basis varchar(20) NOT NULL,
+  value double precision NOT NULL,
+  inclusive boolean NOT NULL DEFAULT false,
+  amount double precision NOT NULL
+);
+CREATE INDEX IF NOT EXISTS t_booking_line_items_fk_booking_id_idx ON t_booking_line_items (fk_booking_id);
@@ -0,0 +1,4 @@
+ALTER TABLE t_booking_room_types
+  DROP COLUMN IF EXISTS amount;
+ALTER TABLE t_booking_rooms
+  DROP COLUMN IF EXISTS amount;
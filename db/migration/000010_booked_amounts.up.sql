@@ -0,0 +1,23 @@
+-- What each room, or the rooms of each room type, cost for the whole stay
+-- when booked. A change of dates keeps that price for the nights that stay
+-- booked. Units assigned to a room type at check-in are priced through it
+-- and keep 0.
+ALTER TABLE t_booking_rooms
+  ADD COLUMN IF NOT EXISTS amount double precision NOT NULL DEFAULT 0;
+ALTER TABLE t_booking_room_types
+  ADD COLUMN IF NOT EXISTS amount double precision NOT NULL DEFAULT 0;
+
+-- Bookings made before take the current prices, the booked ones being
+-- unknown
+UPDATE t_booking_rooms
+SET amount = t_rooms.price * t_bookings.nights
+FROM t_rooms, t_bookings
+WHERE t_rooms.id = t_booking_rooms.fk_room_id
+  AND t_bookings.id = t_booking_rooms.fk_booking_id
+  AND t_rooms.fk_room_type_id IS NULL;
+
+UPDATE t_booking_room_types
+SET amount = t_room_types.price * t_bookings.nights * t_booking_room_types.quantity
+FROM t_room_types, t_bookings
+WHERE t_room_types.id = t_booking_room_types.fk_room_type_id
+  AND t_bookings.id = t_booking_room_types.fk_booking_id;
@@ -42,6 +42,15 @@ type T_Properties struct {
 	Fk_Argent_Id    uint            `gorm:"not null" json:"fk_argent_id"`
 	Status          string          `gorm:"type:varchar(50)" json:"status"`
 	Type            string          `gorm:"type:varchar(50)" json:"type"`
+	// Local "HH:MM" times; empty check-in and check-out times use the server
+	// defaults, nil early and late times mean the option is not offered
+	Check_In_Time       string  `gorm:"type:varchar(5)" json:"check_in_time"`
+	Check_Out_Time      string  `gorm:"type:varchar(5)" json:"check_out_time"`
+	Early_Check_In_Time *string `gorm:"type:varchar(5)" json:"early_check_in_time"`
+	Late_Check_Out_Time *string `gorm:"type:varchar(5)" json:"late_check_out_time"`
+	// IANA time zone the times above and stay dates are in; empty uses the
+	// server default
+	Timezone string `gorm:"type:varchar(64)" json:"timezone"`
 }
 
 // Room struct definition with embedded
@@ -51,6 +60,38 @@ type T_Rooms struct {
 	Name           string `gorm:"type:varchar(100)" json:"name"`
 	Status         string `gorm:"type:varchar(50)" json:"status"`
 	Price          uint   `gorm:"not null" json:"price"`
+	// Most guests the room sleeps; children may also take free adult places
+	Max_Adults   uint `gorm:"not null" json:"max_adults"`
+	Max_Children uint `gorm:"not null" json:"max_children"`
+	Beds         uint `gorm:"not null" json:"beds"`
+	// Set when the room is a unit of a room type. Units are booked through
+	// their type and copy its price and occupancy.
+	Fk_Room_Type_Id *uint `json:"fk_room_type_id"`
+}
+
+// RoomType groups interchangeable rooms of a property that share a price,
+// occupancy, amenities and images
+type T_Room_Types struct {
+	Id             uint   `gorm:"primaryKey;autoIncrement" json:"id"`
+	Fk_Property_Id uint   `gorm:"not null;index" json:"fk_property_id"`
+	Name           string `gorm:"type:varchar(100)" json:"name"`
+	Status         string `gorm:"type:varchar(50)" json:"status"`
+	Price          uint   `gorm:"not null" json:"price"`
+	Max_Adults     uint   `gorm:"not null" json:"max_adults"`
+	Max_Children   uint   `gorm:"not null" json:"max_children"`
+	Beds           uint   `gorm:"not null" json:"beds"`
+}
+
+type T_Room_Type_Images struct {
+	Id              uint   `gorm:"primaryKey;autoIncrement" json:"id"`
+	Url             string `gorm:"type:varchar(255)" json:"url"`
+	Fk_Room_Type_Id uint   `gorm:"not null" json:"fk_room_type_id"`
+}
+
+type T_Room_Type_Amenities struct {
+	Id              uint `gorm:"primaryKey;autoIncrement" json:"id"`
+	Fk_Room_Type_Id uint `gorm:"not null" json:"fk_room_type_id"`
+	Fk_Amenity_Id   uint `gorm:"not null" json:"fk_amenity_id"`
 }
 type T_Agent_Staffs struct {
 	Id       uint `json:"id"`
@@ -66,7 +107,7 @@ type T_Booking_Deposits struct {
 
 type T_Banks struct {
 	ID             uint      `json:"id"`
-	Bank_Name      string    `" json:"bank_name"`
+	Bank_Name      string    `json:"bank_name"`
 	Account_Number string    ` json:"image"`
 	QR_Code        *string   ` json:"deposit"`
 	Fk_Argent_Id   uint      `json:"fk_argent_id"`
@@ -121,11 +162,133 @@ type T_Bookings struct {
 	Create_At      time.Time ` json:"create_at"`
 	Total_Price    float64   `gorm:"not null" json:"total_price"`
 	Fk_Property_Id uint      `gorm:"not null" json:"fk_property_id"`
+	// Nights is the number of nights between the local check-in and
+	// check-out dates. Start_Date and End_Date are the check-in and
+	// check-out instants, moved by the early and late options.
+	Nights         int  `gorm:"not null" json:"nights"`
+	Early_Check_In bool `json:"early_check_in"`
+	Late_Check_Out bool `json:"late_check_out"`
+	// Total_Price is Subtotal_Price, the price of the rooms, less the
+	// Discount of the promotion the booking redeemed, if any, plus the
+	// exclusive taxes and fees among its line items.
+	Subtotal_Price  float64 `gorm:"not null" json:"subtotal_price"`
+	Discount        float64 `gorm:"not null" json:"discount"`
+	Fk_Promotion_Id *uint   `json:"fk_promotion_id"`
 }
 type T_Booking_Rooms struct {
 	Id            uint ` json:"id"`
 	Fk_Room_Id    uint `gorm:"not null" json:"fk_room_id"`
 	Fk_Booking_id uint `gorm:"not null" json:"fk_booking_id"`
+	// Guests staying in the room
+	Adults   uint `gorm:"not null" json:"adults"`
+	Children uint `gorm:"not null" json:"children"`
+	// Price of the room for the whole stay as booked; 0 for the units of a
+	// room type, which are priced through it
+	Amount float64 `gorm:"not null" json:"amount"`
+}
+
+// BookingRoomType is a number of rooms of a type reserved by a booking. The
+// units are assigned at check-in as T_Booking_Rooms rows.
+type T_Booking_Room_Types struct {
+	Id              uint `gorm:"primaryKey;autoIncrement" json:"id"`
+	Fk_Booking_Id   uint `gorm:"not null;index" json:"fk_booking_id"`
+	Fk_Room_Type_Id uint `gorm:"not null;index" json:"fk_room_type_id"`
+	Quantity        uint `gorm:"not null" json:"quantity"`
+	// Guests staying in each of the rooms
+	Adults   uint `gorm:"not null" json:"adults"`
+	Children uint `gorm:"not null" json:"children"`
+	// Price of all the rooms for the whole stay as booked
+	Amount float64 `gorm:"not null" json:"amount"`
+}
+
+// BookingStatusHistory struct definition with embedded
+type T_Booking_Status_Histories struct {
+	Id            uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	Fk_Booking_Id uint      `gorm:"not null;index" json:"fk_booking_id"`
+	From_Status   string    `gorm:"type:varchar(50)" json:"from_status"`
+	To_Status     string    `gorm:"type:varchar(50);not null" json:"to_status"`
+	Create_At     time.Time `json:"create_at"`
+	// Note describes changes other than the status, such as new dates or
+	// rooms; the status is unchanged for those entries
+	Note string `gorm:"type:text" json:"note"`
+}
+
+// StayRestriction limits the stays that may be booked at a property, or at
+// one of its rooms or room types when either is set. The rule applies to
+// local dates from Start_Date to End_Date inclusive, on the weekdays of
+// Weekdays (bit n for time.Weekday n, 0 for every day). Minimum and
+// maximum nights are checked against the arrival date; 0 means no limit.
+type T_Stay_Restrictions struct {
+	Id                  uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	Fk_Property_Id      uint      `gorm:"not null;index" json:"fk_property_id"`
+	Fk_Room_Id          *uint     `json:"fk_room_id"`
+	Fk_Room_Type_Id     *uint     `json:"fk_room_type_id"`
+	Start_Date          time.Time `gorm:"type:date;not null" json:"start_date"`
+	End_Date            time.Time `gorm:"type:date;not null" json:"end_date"`
+	Weekdays            int       `gorm:"not null" json:"weekdays"`
+	Min_Nights          int       `gorm:"not null" json:"min_nights"`
+	Max_Nights          int       `gorm:"not null" json:"max_nights"`
+	Closed_To_Arrival   bool      `json:"closed_to_arrival"`
+	Closed_To_Departure bool      `json:"closed_to_departure"`
+}
+
+// T_Promotions are discount codes of an agent. A promotion applies to
+// stays arriving from Start_Date to End_Date inclusive, local to the
+// property, and to the properties, rooms and room types of its targets, or
+// to every property of the agent when it has none. Min_Nights and
+// Max_Redemptions are not limits when 0.
+type T_Promotions struct {
+	Id              uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	Fk_Agent_Id     uint      `gorm:"not null;index" json:"fk_agent_id"`
+	Code            string    `gorm:"type:varchar(32);not null;uniqueIndex" json:"code"`
+	Name            string    `gorm:"type:varchar(100)" json:"name"`
+	Type            string    `gorm:"type:varchar(20);not null" json:"type"`
+	Value           float64   `gorm:"not null" json:"value"`
+	Start_Date      time.Time `gorm:"type:date;not null" json:"start_date"`
+	End_Date        time.Time `gorm:"type:date;not null" json:"end_date"`
+	Min_Nights      int       `gorm:"not null" json:"min_nights"`
+	Max_Redemptions int       `gorm:"not null" json:"max_redemptions"`
+	Redemptions     int       `gorm:"not null" json:"redemptions"`
+	Create_At       time.Time `json:"create_at"`
+}
+
+// T_Promotion_Targets limit a promotion to a whole property, or to one room
+// or room type of it.
+type T_Promotion_Targets struct {
+	Id              uint  `gorm:"primaryKey;autoIncrement" json:"id"`
+	Fk_Promotion_Id uint  `gorm:"not null;index" json:"fk_promotion_id"`
+	Fk_Property_Id  uint  `gorm:"not null" json:"fk_property_id"`
+	Fk_Room_Id      *uint `json:"fk_room_id"`
+	Fk_Room_Type_Id *uint `json:"fk_room_type_id"`
+}
+
+// T_Property_Charges are the taxes and fees a property adds to bookings.
+// PERCENTAGE charges take Value percent of the room price after discount,
+// PER_NIGHT charges take Value for each room and night. Inclusive charges
+// are already part of the room price and are only shown separately.
+type T_Property_Charges struct {
+	Id             uint    `gorm:"primaryKey;autoIncrement" json:"id"`
+	Fk_Property_Id uint    `gorm:"not null;index" json:"fk_property_id"`
+	Name           string  `gorm:"type:varchar(100);not null" json:"name"`
+	Kind           string  `gorm:"type:varchar(20);not null" json:"kind"`
+	Basis          string  `gorm:"type:varchar(20);not null" json:"basis"`
+	Value          float64 `gorm:"not null" json:"value"`
+	Inclusive      bool    `json:"inclusive"`
+}
+
+// T_Booking_Line_Items are the taxes and fees charged on a booking, copied
+// from the rules of the property when the booking was priced so later
+// changes to the rules leave it alone.
+type T_Booking_Line_Items struct {
+	Id                    uint    `gorm:"primaryKey;autoIncrement" json:"id"`
+	Fk_Booking_Id         uint    `gorm:"not null;index" json:"fk_booking_id"`
+	Fk_Property_Charge_Id *uint   `json:"fk_property_charge_id"`
+	Name                  string  `gorm:"type:varchar(100);not null" json:"name"`
+	Kind                  string  `gorm:"type:varchar(20);not null" json:"kind"`
+	Basis                 string  `gorm:"type:varchar(20);not null" json:"basis"`
+	Value                 float64 `gorm:"not null" json:"value"`
+	Inclusive             bool    `json:"inclusive"`
+	Amount                float64 `gorm:"not null" json:"amount"`
 }
 
 // Province struct definition with embedded
@@ -0,0 +1,99 @@
+package db
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PromotionStore interface {
+	CreatePromotion(ctx context.Context, promotion *T_Promotions) error
+	AddPromotionTargets(ctx context.Context, targets []T_Promotion_Targets) error
+	GetPromotion(ctx context.Context, id uint) (T_Promotions, error)
+	// LockPromotion is GetPromotion holding a lock on the row until the
+	// transaction ends.
+	LockPromotion(ctx context.Context, id uint) (T_Promotions, error)
+	GetPromotionByCode(ctx context.Context, code string) (T_Promotions, error)
+	// LockPromotionByCode is GetPromotionByCode holding a lock on the row
+	// until the transaction ends, so redemptions of the same code run one
+	// at a time.
+	LockPromotionByCode(ctx context.Context, code string) (T_Promotions, error)
+	ListPromotionsByIds(ctx context.Context, ids []uint) ([]T_Promotions, error)
+	ListPromotionsByAgent(ctx context.Context, agentId uint) ([]T_Promotions, error)
+	ListPromotionTargets(ctx context.Context, promotionIds []uint) ([]T_Promotion_Targets, error)
+	// RedeemPromotion counts one more redemption of the promotion.
+	RedeemPromotion(ctx context.Context, id uint) error
+	// ReleasePromotion counts one redemption less, as when the booking that
+	// redeemed the promotion is canceled.
+	ReleasePromotion(ctx context.Context, id uint) error
+}
+
+func (store *PostgresStore) CreatePromotion(ctx context.Context, promotion *T_Promotions) error {
+	return store.conn(ctx).Create(promotion).Error
+}
+
+func (store *PostgresStore) AddPromotionTargets(ctx context.Context, targets []T_Promotion_Targets) error {
+	if len(targets) == 0 {
+		return nil
+	}
+	return store.conn(ctx).Create(&targets).Error
+}
+
+func (store *PostgresStore) GetPromotion(ctx context.Context, id uint) (T_Promotions, error) {
+	var promotion T_Promotions
+	err := store.conn(ctx).Where("id = ?", id).First(&promotion).Error
+	return promotion, notFound(err)
+}
+
+func (store *PostgresStore) LockPromotion(ctx context.Context, id uint) (T_Promotions, error) {
+	var promotion T_Promotions
+	err := store.conn(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&promotion).Error
+	return promotion, notFound(err)
+}
+
+func (store *PostgresStore) GetPromotionByCode(ctx context.Context, code string) (T_Promotions, error) {
+	var promotion T_Promotions
+	err := store.conn(ctx).Where("code = ?", code).First(&promotion).Error
+	return promotion, notFound(err)
+}
+
+func (store *PostgresStore) LockPromotionByCode(ctx context.Context, code string) (T_Promotions, error) {
+	var promotion T_Promotions
+	err := store.conn(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where("code = ?", code).First(&promotion).Error
+	return promotion, notFound(err)
+}
+
+func (store *PostgresStore) ListPromotionsByIds(ctx context.Context, ids []uint) ([]T_Promotions, error) {
+	var promotions []T_Promotions
+	if len(ids) == 0 {
+		return promotions, nil
+	}
+	err := store.conn(ctx).Where("id IN ?", ids).Find(&promotions).Error
+	return promotions, err
+}
+
+func (store *PostgresStore) ListPromotionsByAgent(ctx context.Context, agentId uint) ([]T_Promotions, error) {
+	var promotions []T_Promotions
+	err := store.conn(ctx).Where("fk_agent_id = ?", agentId).Order("id DESC").Find(&promotions).Error
+	return promotions, err
+}
+
+func (store *PostgresStore) ListPromotionTargets(ctx context.Context, promotionIds []uint) ([]T_Promotion_Targets, error) {
+	var targets []T_Promotion_Targets
+	if len(promotionIds) == 0 {
+		return targets, nil
+	}
+	err := store.conn(ctx).Where("fk_promotion_id IN ?", promotionIds).Order("id").Find(&targets).Error
+	return targets, err
+}
+
+func (store *PostgresStore) RedeemPromotion(ctx context.Context, id uint) error {
+	return store.conn(ctx).Model(&T_Promotions{}).Where("id = ?", id).
+		Update("redemptions", gorm.Expr("redemptions + 1")).Error
+}
+
+func (store *PostgresStore) ReleasePromotion(ctx context.Context, id uint) error {
+	return store.conn(ctx).Model(&T_Promotions{}).Where("id = ? AND redemptions > 0", id).
+		Update("redemptions", gorm.Expr("redemptions - 1")).Error
+}
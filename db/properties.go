@@ -0,0 +1,120 @@
+package db
+
+import (
+	"context"
+)
+
+// PropertyAmenity is an amenity together with the property offering it.
+type PropertyAmenity struct {
+	Fk_Property_Id uint
+	T_Amenities
+}
+
+type PropertyStore interface {
+	GetProperty(ctx context.Context, id uint) (T_Properties, error)
+	ListPropertiesByIds(ctx context.Context, ids []uint) ([]T_Properties, error)
+	// ListPropertiesByAgent returns the agent's properties, leaving out the
+	// ones whose status is excludeStatus when it is not empty.
+	ListPropertiesByAgent(ctx context.Context, agentId uint, excludeStatus string) ([]T_Properties, error)
+	CountPropertiesByAgent(ctx context.Context, agentId uint) (int64, error)
+	CreateProperty(ctx context.Context, property *T_Properties) error
+	// UpdatePropertyStatus returns ErrNotFound when no row has the given id.
+	UpdatePropertyStatus(ctx context.Context, id uint, status string) error
+
+	AddPropertyImages(ctx context.Context, propertyId uint, urls []string) error
+	ListPropertyImages(ctx context.Context, propertyIds []uint) ([]T_Property_Images, error)
+	AddPropertyAmenities(ctx context.Context, propertyId uint, amenityIds []uint) error
+	ListPropertyAmenities(ctx context.Context, propertyIds []uint) ([]PropertyAmenity, error)
+}
+
+func (store *PostgresStore) GetProperty(ctx context.Context, id uint) (T_Properties, error) {
+	var property T_Properties
+	err := store.conn(ctx).Where("id = ?", id).First(&property).Error
+	return property, notFound(err)
+}
+
+func (store *PostgresStore) ListPropertiesByIds(ctx context.Context, ids []uint) ([]T_Properties, error) {
+	var properties []T_Properties
+	if len(ids) == 0 {
+		return properties, nil
+	}
+	err := store.conn(ctx).Where("id IN ?", ids).Order("id").Find(&properties).Error
+	return properties, err
+}
+
+func (store *PostgresStore) ListPropertiesByAgent(ctx context.Context, agentId uint, excludeStatus string) ([]T_Properties, error) {
+	query := store.conn(ctx).Where("fk_argent_id = ?", agentId)
+	if excludeStatus != "" {
+		query = query.Where("status <> ?", excludeStatus)
+	}
+	var properties []T_Properties
+	err := query.Order("id").Find(&properties).Error
+	return properties, err
+}
+
+func (store *PostgresStore) CountPropertiesByAgent(ctx context.Context, agentId uint) (int64, error) {
+	var count int64
+	err := store.conn(ctx).Model(&T_Properties{}).Where("fk_argent_id = ?", agentId).Count(&count).Error
+	return count, err
+}
+
+func (store *PostgresStore) CreateProperty(ctx context.Context, property *T_Properties) error {
+	return store.conn(ctx).Create(property).Error
+}
+
+func (store *PostgresStore) UpdatePropertyStatus(ctx context.Context, id uint, status string) error {
+	result := store.conn(ctx).Model(&T_Properties{}).Where("id = ?", id).Update("status", status)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (store *PostgresStore) AddPropertyImages(ctx context.Context, propertyId uint, urls []string) error {
+	if len(urls) == 0 {
+		return nil
+	}
+	images := make([]T_Property_Images, 0, len(urls))
+	for _, url := range urls {
+		images = append(images, T_Property_Images{Url: url, Fk_Property_Id: propertyId})
+	}
+	return store.conn(ctx).Create(&images).Error
+}
+
+func (store *PostgresStore) ListPropertyImages(ctx context.Context, propertyIds []uint) ([]T_Property_Images, error) {
+	var images []T_Property_Images
+	if len(propertyIds) == 0 {
+		return images, nil
+	}
+	err := store.conn(ctx).Where("fk_property_id IN ?", propertyIds).Order("id").Find(&images).Error
+	return images, err
+}
+
+func (store *PostgresStore) AddPropertyAmenities(ctx context.Context, propertyId uint, amenityIds []uint) error {
+	if len(amenityIds) == 0 {
+		return nil
+	}
+	amenities := make([]T_Property_Amenities, 0, len(amenityIds))
+	for _, amenityId := range amenityIds {
+		amenities = append(amenities, T_Property_Amenities{Fk_Property_Id: propertyId, Fk_Amenity_Id: amenityId})
+	}
+	return store.conn(ctx).Create(&amenities).Error
+}
+
+func (store *PostgresStore) ListPropertyAmenities(ctx context.Context, propertyIds []uint) ([]PropertyAmenity, error) {
+	var amenities []PropertyAmenity
+	if len(propertyIds) == 0 {
+		return amenities, nil
+	}
+	err := store.conn(ctx).Table("t_property_amenities").
+		Select("t_property_amenities.fk_property_id, t_amenities.*").
+		Joins("JOIN t_amenities ON t_amenities.id = t_property_amenities.fk_amenity_id").
+		Where("t_amenities.is_deleted = ?", false).
+		Where("t_property_amenities.fk_property_id IN ?", propertyIds).
+		Order("t_property_amenities.id").
+		Scan(&amenities).Error
+	return amenities, err
+}
@@ -0,0 +1,109 @@
+package db
+
+import (
+	"context"
+
+	"gorm.io/gorm/clause"
+)
+
+// RoomTypeAmenity is an amenity together with the room type offering it.
+type RoomTypeAmenity struct {
+	Fk_Room_Type_Id uint
+	T_Amenities
+}
+
+type RoomTypeStore interface {
+	GetRoomType(ctx context.Context, id uint) (T_Room_Types, error)
+	// LockRoomType is GetRoomType holding a lock on the row until the
+	// transaction ends, so bookings of the same type run one at a time.
+	LockRoomType(ctx context.Context, id uint) (T_Room_Types, error)
+	ListRoomTypesByProperties(ctx context.Context, propertyIds []uint) ([]T_Room_Types, error)
+	CreateRoomType(ctx context.Context, roomType *T_Room_Types) error
+	// ListRoomTypeUnits returns the rooms that are units of the given types.
+	ListRoomTypeUnits(ctx context.Context, roomTypeIds []uint) ([]T_Rooms, error)
+
+	AddRoomTypeImages(ctx context.Context, roomTypeId uint, urls []string) error
+	ListRoomTypeImages(ctx context.Context, roomTypeIds []uint) ([]T_Room_Type_Images, error)
+	AddRoomTypeAmenities(ctx context.Context, roomTypeId uint, amenityIds []uint) error
+	ListRoomTypeAmenities(ctx context.Context, roomTypeIds []uint) ([]RoomTypeAmenity, error)
+}
+
+func (store *PostgresStore) GetRoomType(ctx context.Context, id uint) (T_Room_Types, error) {
+	var roomType T_Room_Types
+	err := store.conn(ctx).Where("id = ?", id).First(&roomType).Error
+	return roomType, notFound(err)
+}
+
+func (store *PostgresStore) LockRoomType(ctx context.Context, id uint) (T_Room_Types, error) {
+	var roomType T_Room_Types
+	err := store.conn(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&roomType).Error
+	return roomType, notFound(err)
+}
+
+func (store *PostgresStore) ListRoomTypesByProperties(ctx context.Context, propertyIds []uint) ([]T_Room_Types, error) {
+	var roomTypes []T_Room_Types
+	if len(propertyIds) == 0 {
+		return roomTypes, nil
+	}
+	err := store.conn(ctx).Where("fk_property_id IN ?", propertyIds).Order("id").Find(&roomTypes).Error
+	return roomTypes, err
+}
+
+func (store *PostgresStore) CreateRoomType(ctx context.Context, roomType *T_Room_Types) error {
+	return store.conn(ctx).Create(roomType).Error
+}
+
+func (store *PostgresStore) ListRoomTypeUnits(ctx context.Context, roomTypeIds []uint) ([]T_Rooms, error) {
+	var rooms []T_Rooms
+	if len(roomTypeIds) == 0 {
+		return rooms, nil
+	}
+	err := store.conn(ctx).Where("fk_room_type_id IN ?", roomTypeIds).Order("id").Find(&rooms).Error
+	return rooms, err
+}
+
+func (store *PostgresStore) AddRoomTypeImages(ctx context.Context, roomTypeId uint, urls []string) error {
+	if len(urls) == 0 {
+		return nil
+	}
+	images := make([]T_Room_Type_Images, 0, len(urls))
+	for _, url := range urls {
+		images = append(images, T_Room_Type_Images{Url: url, Fk_Room_Type_Id: roomTypeId})
+	}
+	return store.conn(ctx).Create(&images).Error
+}
+
+func (store *PostgresStore) ListRoomTypeImages(ctx context.Context, roomTypeIds []uint) ([]T_Room_Type_Images, error) {
+	var images []T_Room_Type_Images
+	if len(roomTypeIds) == 0 {
+		return images, nil
+	}
+	err := store.conn(ctx).Where("fk_room_type_id IN ?", roomTypeIds).Order("id").Find(&images).Error
+	return images, err
+}
+
+func (store *PostgresStore) AddRoomTypeAmenities(ctx context.Context, roomTypeId uint, amenityIds []uint) error {
+	if len(amenityIds) == 0 {
+		return nil
+	}
+	amenities := make([]T_Room_Type_Amenities, 0, len(amenityIds))
+	for _, amenityId := range amenityIds {
+		amenities = append(amenities, T_Room_Type_Amenities{Fk_Room_Type_Id: roomTypeId, Fk_Amenity_Id: amenityId})
+	}
+	return store.conn(ctx).Create(&amenities).Error
+}
+
+func (store *PostgresStore) ListRoomTypeAmenities(ctx context.Context, roomTypeIds []uint) ([]RoomTypeAmenity, error) {
+	var amenities []RoomTypeAmenity
+	if len(roomTypeIds) == 0 {
+		return amenities, nil
+	}
+	err := store.conn(ctx).Table("t_room_type_amenities").
+		Select("t_room_type_amenities.fk_room_type_id, t_amenities.*").
+		Joins("JOIN t_amenities ON t_amenities.id = t_room_type_amenities.fk_amenity_id").
+		Where("t_amenities.is_deleted = ?", false).
+		Where("t_room_type_amenities.fk_room_type_id IN ?", roomTypeIds).
+		Order("t_room_type_amenities.id").
+		Scan(&amenities).Error
+	return amenities, err
+}
@@ -0,0 +1,106 @@
+package db
+
+import (
+	"context"
+)
+
+// RoomAmenity is an amenity together with the room offering it.
+type RoomAmenity struct {
+	Fk_Room_Id uint
+	T_Amenities
+}
+
+type RoomStore interface {
+	GetRoom(ctx context.Context, id uint) (T_Rooms, error)
+	// ListRoomsByProperties returns the rooms of the given properties,
+	// leaving out the ones whose status is excludeStatus when it is not empty.
+	ListRoomsByProperties(ctx context.Context, propertyIds []uint, excludeStatus string) ([]T_Rooms, error)
+	CreateRoom(ctx context.Context, room *T_Rooms) error
+	// UpdateRoomStatus returns ErrNotFound when no row has the given id.
+	UpdateRoomStatus(ctx context.Context, id uint, status string) error
+
+	AddRoomImages(ctx context.Context, roomId uint, urls []string) error
+	ListRoomImages(ctx context.Context, roomIds []uint) ([]T_Room_Images, error)
+	AddRoomAmenities(ctx context.Context, roomId uint, amenityIds []uint) error
+	ListRoomAmenities(ctx context.Context, roomIds []uint) ([]RoomAmenity, error)
+}
+
+func (store *PostgresStore) GetRoom(ctx context.Context, id uint) (T_Rooms, error) {
+	var room T_Rooms
+	err := store.conn(ctx).Where("id = ?", id).First(&room).Error
+	return room, notFound(err)
+}
+
+func (store *PostgresStore) ListRoomsByProperties(ctx context.Context, propertyIds []uint, excludeStatus string) ([]T_Rooms, error) {
+	var rooms []T_Rooms
+	if len(propertyIds) == 0 {
+		return rooms, nil
+	}
+	query := store.conn(ctx).Where("fk_property_id IN ?", propertyIds)
+	if excludeStatus != "" {
+		query = query.Where("status <> ?", excludeStatus)
+	}
+	err := query.Order("id").Find(&rooms).Error
+	return rooms, err
+}
+
+func (store *PostgresStore) CreateRoom(ctx context.Context, room *T_Rooms) error {
+	return store.conn(ctx).Create(room).Error
+}
+
+func (store *PostgresStore) UpdateRoomStatus(ctx context.Context, id uint, status string) error {
+	result := store.conn(ctx).Model(&T_Rooms{}).Where("id = ?", id).Update("status", status)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (store *PostgresStore) AddRoomImages(ctx context.Context, roomId uint, urls []string) error {
+	if len(urls) == 0 {
+		return nil
+	}
+	images := make([]T_Room_Images, 0, len(urls))
+	for _, url := range urls {
+		images = append(images, T_Room_Images{Url: url, Fk_Room_Id: roomId})
+	}
+	return store.conn(ctx).Create(&images).Error
+}
+
+func (store *PostgresStore) ListRoomImages(ctx context.Context, roomIds []uint) ([]T_Room_Images, error) {
+	var images []T_Room_Images
+	if len(roomIds) == 0 {
+		return images, nil
+	}
+	err := store.conn(ctx).Where("fk_room_id IN ?", roomIds).Order("id").Find(&images).Error
+	return images, err
+}
+
+func (store *PostgresStore) AddRoomAmenities(ctx context.Context, roomId uint, amenityIds []uint) error {
+	if len(amenityIds) == 0 {
+		return nil
+	}
+	amenities := make([]T_Room_Amenities, 0, len(amenityIds))
+	for _, amenityId := range amenityIds {
+		amenities = append(amenities, T_Room_Amenities{Fk_Room_Id: roomId, Fk_Amenity_Id: amenityId})
+	}
+	return store.conn(ctx).Create(&amenities).Error
+}
+
+func (store *PostgresStore) ListRoomAmenities(ctx context.Context, roomIds []uint) ([]RoomAmenity, error) {
+	var amenities []RoomAmenity
+	if len(roomIds) == 0 {
+		return amenities, nil
+	}
+	err := store.conn(ctx).Table("t_room_amenities").
+		Select("t_room_amenities.fk_room_id, t_amenities.*").
+		Joins("JOIN t_amenities ON t_amenities.id = t_room_amenities.fk_amenity_id").
+		Where("t_amenities.is_deleted = ?", false).
+		Where("t_room_amenities.fk_room_id IN ?", roomIds).
+		Order("t_room_amenities.id").
+		Scan(&amenities).Error
+	return amenities, err
+}
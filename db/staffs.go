@@ -0,0 +1,36 @@
+package db
+
+import (
+	"context"
+)
+
+type StaffStore interface {
+	GetUser(ctx context.Context, id uint) (T_Users, error)
+	CreateUser(ctx context.Context, user *T_Users) error
+	AddAgentStaff(ctx context.Context, agentStaff *T_Agent_Staffs) error
+	ListStaffByAgent(ctx context.Context, agentId uint) ([]T_Users, error)
+}
+
+func (store *PostgresStore) GetUser(ctx context.Context, id uint) (T_Users, error) {
+	var user T_Users
+	err := store.conn(ctx).Where("id = ?", id).First(&user).Error
+	return user, notFound(err)
+}
+
+func (store *PostgresStore) CreateUser(ctx context.Context, user *T_Users) error {
+	return store.conn(ctx).Create(user).Error
+}
+
+func (store *PostgresStore) AddAgentStaff(ctx context.Context, agentStaff *T_Agent_Staffs) error {
+	return store.conn(ctx).Create(agentStaff).Error
+}
+
+func (store *PostgresStore) ListStaffByAgent(ctx context.Context, agentId uint) ([]T_Users, error) {
+	var staffs []T_Users
+	err := store.conn(ctx).Table("t_users").
+		Joins("JOIN t_agent_staffs ON t_users.id = t_agent_staffs.staff_id").
+		Where("t_agent_staffs.agent_id = ?", agentId).
+		Order("t_users.id").
+		Find(&staffs).Error
+	return staffs, err
+}
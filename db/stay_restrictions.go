@@ -0,0 +1,35 @@
+package db
+
+import (
+	"context"
+)
+
+type StayRestrictionStore interface {
+	CreateStayRestriction(ctx context.Context, restriction *T_Stay_Restrictions) error
+	// ListStayRestrictions returns every restriction of the property,
+	// including those of its rooms and room types.
+	ListStayRestrictions(ctx context.Context, propertyId uint) ([]T_Stay_Restrictions, error)
+	// DeleteStayRestriction returns ErrNotFound when no row has the given id.
+	DeleteStayRestriction(ctx context.Context, id uint) error
+}
+
+func (store *PostgresStore) CreateStayRestriction(ctx context.Context, restriction *T_Stay_Restrictions) error {
+	return store.conn(ctx).Create(restriction).Error
+}
+
+func (store *PostgresStore) ListStayRestrictions(ctx context.Context, propertyId uint) ([]T_Stay_Restrictions, error) {
+	var restrictions []T_Stay_Restrictions
+	err := store.conn(ctx).Where("fk_property_id = ?", propertyId).Order("start_date, id").Find(&restrictions).Error
+	return restrictions, err
+}
+
+func (store *PostgresStore) DeleteStayRestriction(ctx context.Context, id uint) error {
+	result := store.conn(ctx).Where("id = ?", id).Delete(&T_Stay_Restrictions{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
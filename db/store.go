@@ -0,0 +1,71 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// ErrNotFound is returned by every store when the requested row does not exist.
+var ErrNotFound = errors.New("record not found")
+
+// Store provides all functions to execute db queries and transactions.
+type Store interface {
+	BookingStore
+	PropertyStore
+	RoomStore
+	RoomTypeStore
+	StayRestrictionStore
+	PromotionStore
+	PropertyChargeStore
+	BankStore
+	StaffStore
+
+	// ExecTx runs fn inside a transaction. The Store passed to fn must be used
+	// for every query that belongs to the transaction; returning an error from
+	// fn rolls back everything it did.
+	ExecTx(ctx context.Context, fn func(Store) error) error
+
+	// Ping reports whether the store can currently serve queries.
+	Ping(ctx context.Context) error
+}
+
+// PostgresStore implements Store on top of a gorm connection.
+type PostgresStore struct {
+	db *gorm.DB
+}
+
+// NewPostgresStore creates a Store backed by the given gorm connection.
+func NewPostgresStore(conn *gorm.DB) *PostgresStore {
+	return &PostgresStore{db: conn}
+}
+
+func (store *PostgresStore) ExecTx(ctx context.Context, fn func(Store) error) error {
+	return store.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&PostgresStore{db: tx})
+	})
+}
+
+func (store *PostgresStore) Ping(ctx context.Context) error {
+	sqlDB, err := store.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// conn returns the connection bound to ctx.
+func (store *PostgresStore) conn(ctx context.Context) *gorm.DB {
+	return store.db.WithContext(ctx)
+}
+
+// notFound translates gorm's missing-row error into ErrNotFound.
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+var _ Store = (*PostgresStore)(nil)
@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lancer2672/BookingAppSubServer/internal/utils"
+)
+
+// Storage keeps uploaded files and returns the public URL of each one.
+type Storage interface {
+	Save(ctx context.Context, filename string, content io.Reader) (string, error)
+	// Check reports whether new files can currently be stored.
+	Check(ctx context.Context) error
+}
+
+// New creates the storage backend selected by config.StorageBackend.
+func New(config utils.Config) (Storage, error) {
+	switch config.StorageBackend {
+	case utils.StorageBackendLocal:
+		return NewLocalStorage(config.UploadDir, strings.TrimRight(config.PublicURL, "/")+"/uploads")
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", config.StorageBackend)
+	}
+}
+
+// LocalStorage writes files to a directory that the server serves itself.
+type LocalStorage struct {
+	dir     string
+	baseURL string
+}
+
+func NewLocalStorage(dir, baseURL string) (*LocalStorage, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("cannot create upload directory: %w", err)
+	}
+	return &LocalStorage{dir: dir, baseURL: baseURL}, nil
+}
+
+// Save stores content under a random name that keeps the extension of
+// filename, so uploads never overwrite each other or escape the directory.
+func (storage *LocalStorage) Save(ctx context.Context, filename string, content io.Reader) (string, error) {
+	name, err := randomName(filepath.Ext(filename))
+	if err != nil {
+		return "", err
+	}
+
+	out, err := os.Create(filepath.Join(storage.dir, name))
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(out, content); err != nil {
+		out.Close()
+		os.Remove(out.Name())
+		return "", err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(out.Name())
+		return "", err
+	}
+	return storage.baseURL + "/" + name, nil
+}
+
+// Check writes and removes a probe file in the upload directory.
+func (storage *LocalStorage) Check(ctx context.Context) error {
+	probe, err := os.CreateTemp(storage.dir, ".probe-*")
+	if err != nil {
+		return err
+	}
+	probe.Close()
+	return os.Remove(probe.Name())
+}
+
+func randomName(ext string) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b) + strings.ToLower(ext), nil
+}
@@ -1,18 +1,140 @@
 package utils
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
 	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/viper"
 )
 
+// Config holds every setting of the server. Fields tagged redact are secrets
+// and are masked by Print.
 type Config struct {
 	DBDriver      string `mapstructure:"DB_DRIVER"`
 	Environment   string `mapstructure:"ENVIRONMENT"`
-	DBSource      string `mapstructure:"DB_SOURCE"`
+	DBSource      string `mapstructure:"DB_SOURCE" redact:"dsn"`
 	ServerAddress string `mapstructure:"SERVER_ADDRESS"`
+	// PublicURL is the externally reachable base URL used to build links to
+	// uploaded files.
+	PublicURL string `mapstructure:"PUBLIC_URL"`
+
+	// Connection pool
+	DBMaxOpenConns    int           `mapstructure:"DB_MAX_OPEN_CONNS"`
+	DBMaxIdleConns    int           `mapstructure:"DB_MAX_IDLE_CONNS"`
+	DBConnMaxLifetime time.Duration `mapstructure:"DB_CONN_MAX_LIFETIME"`
+	DBConnMaxIdleTime time.Duration `mapstructure:"DB_CONN_MAX_IDLE_TIME"`
+
+	// HTTP server timeouts, e.g. "15s"
+	ReadTimeout     time.Duration `mapstructure:"READ_TIMEOUT"`
+	WriteTimeout    time.Duration `mapstructure:"WRITE_TIMEOUT"`
+	IdleTimeout     time.Duration `mapstructure:"IDLE_TIMEOUT"`
+	ShutdownTimeout time.Duration `mapstructure:"SHUTDOWN_TIMEOUT"`
+
+	// CORS, an empty origin list falls back to the environment default
+	CORSAllowedOrigins   []string      `mapstructure:"CORS_ALLOWED_ORIGINS"`
+	CORSAllowedMethods   []string      `mapstructure:"CORS_ALLOWED_METHODS"`
+	CORSAllowedHeaders   []string      `mapstructure:"CORS_ALLOWED_HEADERS"`
+	CORSAllowCredentials bool          `mapstructure:"CORS_ALLOW_CREDENTIALS"`
+	CORSMaxAge           time.Duration `mapstructure:"CORS_MAX_AGE"`
+
+	TokenSymmetricKey   string        `mapstructure:"TOKEN_SYMMETRIC_KEY" redact:"true"`
+	AccessTokenDuration time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
+
+	// Uploaded files
+	StorageBackend string `mapstructure:"STORAGE_BACKEND"`
+	UploadDir      string `mapstructure:"UPLOAD_DIR"`
+	UploadMaxBytes int64  `mapstructure:"UPLOAD_MAX_BYTES"`
+
+	// Logging
+	LogLevel           string        `mapstructure:"LOG_LEVEL"`
+	SlowQueryThreshold time.Duration `mapstructure:"SLOW_QUERY_THRESHOLD"`
+
+	// Tracing
+	TracingExporter     string  `mapstructure:"TRACING_EXPORTER"`
+	TracingOTLPEndpoint string  `mapstructure:"TRACING_OTLP_ENDPOINT"`
+	TracingServiceName  string  `mapstructure:"TRACING_SERVICE_NAME"`
+	TracingSampleRatio  float64 `mapstructure:"TRACING_SAMPLE_RATIO"`
+
+	// Stays. PropertyTimezone, CheckInTime and CheckOutTime apply to
+	// properties that do not set their own; times are "HH:MM" local times.
+	PropertyTimezone string `mapstructure:"PROPERTY_TIMEZONE"`
+	CheckInTime      string `mapstructure:"CHECK_IN_TIME"`
+	CheckOutTime     string `mapstructure:"CHECK_OUT_TIME"`
+	// CheckInWindow is how long after the check-in time guests can still be
+	// checked in; 0 lets them check in at any time.
+	CheckInWindow time.Duration `mapstructure:"CHECK_IN_WINDOW"`
+}
+
+// Supported values of Config.Environment, Config.StorageBackend and
+// Config.TracingExporter
+const (
+	EnvironmentDevelopment = "development"
+	EnvironmentTest        = "test"
+	EnvironmentStaging     = "staging"
+	EnvironmentProduction  = "production"
+
+	StorageBackendLocal = "local"
+
+	TracingExporterNone   = "none"
+	TracingExporterStdout = "stdout"
+	TracingExporterOTLP   = "otlp"
+)
+
+// TimeOfDayLayout is the layout of check-in and check-out times.
+const TimeOfDayLayout = "15:04"
+
+var configDefaults = map[string]interface{}{
+	"DB_DRIVER":      "postgres",
+	"ENVIRONMENT":    EnvironmentDevelopment,
+	"DB_SOURCE":      "",
+	"SERVER_ADDRESS": "0.0.0.0:8080",
+	"PUBLIC_URL":     "https://bookingappsubserver.onrender.com",
+
+	"DB_MAX_OPEN_CONNS":     25,
+	"DB_MAX_IDLE_CONNS":     10,
+	"DB_CONN_MAX_LIFETIME":  30 * time.Minute,
+	"DB_CONN_MAX_IDLE_TIME": 5 * time.Minute,
+
+	"READ_TIMEOUT":     15 * time.Second,
+	"WRITE_TIMEOUT":    30 * time.Second,
+	"IDLE_TIMEOUT":     60 * time.Second,
+	"SHUTDOWN_TIMEOUT": 20 * time.Second,
+
+	"CORS_ALLOWED_ORIGINS":   []string{},
+	"CORS_ALLOWED_METHODS":   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+	"CORS_ALLOWED_HEADERS":   []string{"Origin", "Content-Type", "Accept", "Authorization", "Accept-Language", "X-Request-ID"},
+	"CORS_ALLOW_CREDENTIALS": false,
+	"CORS_MAX_AGE":           12 * time.Hour,
+
+	"TOKEN_SYMMETRIC_KEY":   "",
+	"ACCESS_TOKEN_DURATION": 15 * time.Minute,
+
+	"STORAGE_BACKEND":  StorageBackendLocal,
+	"UPLOAD_DIR":       "uploads",
+	"UPLOAD_MAX_BYTES": 10 << 20,
+
+	"LOG_LEVEL":            "info",
+	"SLOW_QUERY_THRESHOLD": 200 * time.Millisecond,
+
+	"TRACING_EXPORTER":      TracingExporterNone,
+	"TRACING_OTLP_ENDPOINT": "",
+	"TRACING_SERVICE_NAME":  "booking-app-sub-server",
+	"TRACING_SAMPLE_RATIO":  1.0,
+
+	"PROPERTY_TIMEZONE": "Asia/Ho_Chi_Minh",
+	"CHECK_IN_TIME":     "14:00",
+	"CHECK_OUT_TIME":    "12:00",
+	"CHECK_IN_WINDOW":   13 * time.Hour,
 }
 
 // overrided by env if exists
@@ -22,18 +144,215 @@ func LoadConfig(path string) (config Config, err error) {
 	viper.SetConfigName("app")
 	viper.AutomaticEnv()
 
+	// Registering every key lets environment variables override them even
+	// when app.env does not mention them.
+	for key, value := range configDefaults {
+		viper.SetDefault(key, value)
+	}
+
 	err = viper.ReadInConfig()
 	if err != nil {
-		return
+		var notFound viper.ConfigFileNotFoundError
+		if !errors.As(err, &notFound) {
+			return
+		}
+		err = nil
 	}
 	err = viper.Unmarshal(&config)
 
-	configProject(config.Environment)
+	configProject(config)
 	return
 }
 
-func configProject(env string) {
-	if env == "development" {
+func configProject(config Config) {
+	if config.Environment == "development" {
 		log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
 	}
+	if level, err := zerolog.ParseLevel(config.LogLevel); err == nil && level != zerolog.NoLevel {
+		zerolog.SetGlobalLevel(level)
+	}
+	// zerolog.Ctx falls back to the global logger for contexts without a
+	// request-scoped one, e.g. background jobs
+	zerolog.DefaultContextLogger = &log.Logger
+}
+
+// ConfigError lists every invalid setting found by Config.Validate.
+type ConfigError struct {
+	Fields []string
+}
+
+func (e *ConfigError) Error() string {
+	return "invalid configuration:\n  " + strings.Join(e.Fields, "\n  ")
+}
+
+// Validate checks every setting and reports all problems at once.
+func (config Config) Validate() error {
+	var fields []string
+	invalid := func(key, format string, args ...interface{}) {
+		fields = append(fields, key+": "+fmt.Sprintf(format, args...))
+	}
+
+	if config.DBDriver != "postgres" {
+		invalid("DB_DRIVER", "must be postgres, got %q", config.DBDriver)
+	}
+	switch config.Environment {
+	case EnvironmentDevelopment, EnvironmentTest, EnvironmentStaging, EnvironmentProduction:
+	default:
+		invalid("ENVIRONMENT", "must be one of development, test, staging, production, got %q", config.Environment)
+	}
+	if config.DBSource == "" {
+		invalid("DB_SOURCE", "is required")
+	}
+	if config.ServerAddress == "" {
+		invalid("SERVER_ADDRESS", "is required")
+	}
+	if u, err := url.Parse(config.PublicURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		invalid("PUBLIC_URL", "must be an absolute http(s) URL, got %q", config.PublicURL)
+	}
+
+	if config.DBMaxOpenConns < 0 {
+		invalid("DB_MAX_OPEN_CONNS", "must not be negative")
+	}
+	if config.DBMaxIdleConns < 0 {
+		invalid("DB_MAX_IDLE_CONNS", "must not be negative")
+	}
+	if config.DBMaxOpenConns > 0 && config.DBMaxIdleConns > config.DBMaxOpenConns {
+		invalid("DB_MAX_IDLE_CONNS", "must not exceed DB_MAX_OPEN_CONNS (%d)", config.DBMaxOpenConns)
+	}
+	if config.DBConnMaxLifetime < 0 {
+		invalid("DB_CONN_MAX_LIFETIME", "must not be negative")
+	}
+	if config.DBConnMaxIdleTime < 0 {
+		invalid("DB_CONN_MAX_IDLE_TIME", "must not be negative")
+	}
+
+	for key, timeout := range map[string]time.Duration{
+		"READ_TIMEOUT":     config.ReadTimeout,
+		"WRITE_TIMEOUT":    config.WriteTimeout,
+		"IDLE_TIMEOUT":     config.IdleTimeout,
+		"SHUTDOWN_TIMEOUT": config.ShutdownTimeout,
+	} {
+		if timeout <= 0 {
+			invalid(key, "must be a positive duration")
+		}
+	}
+
+	for _, origin := range config.CORSAllowedOrigins {
+		if origin == "*" {
+			if config.CORSAllowCredentials {
+				invalid("CORS_ALLOW_CREDENTIALS", "cannot be used with the \"*\" origin")
+			}
+			continue
+		}
+		if u, err := url.Parse(origin); err != nil || u.Scheme == "" || u.Host == "" || (u.Path != "" && u.Path != "/") {
+			invalid("CORS_ALLOWED_ORIGINS", "%q is not an origin like https://example.com", origin)
+		}
+	}
+	for _, method := range config.CORSAllowedMethods {
+		switch strings.ToUpper(method) {
+		case "GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS":
+		default:
+			invalid("CORS_ALLOWED_METHODS", "%q is not a supported HTTP method", method)
+		}
+	}
+	if config.CORSMaxAge < 0 {
+		invalid("CORS_MAX_AGE", "must not be negative")
+	}
+
+	if config.TokenSymmetricKey != "" && len(config.TokenSymmetricKey) != 32 {
+		invalid("TOKEN_SYMMETRIC_KEY", "must be exactly 32 characters")
+	}
+	if config.AccessTokenDuration <= 0 {
+		invalid("ACCESS_TOKEN_DURATION", "must be a positive duration")
+	}
+
+	if config.StorageBackend != StorageBackendLocal {
+		invalid("STORAGE_BACKEND", "must be local, got %q", config.StorageBackend)
+	}
+	if config.UploadDir == "" {
+		invalid("UPLOAD_DIR", "is required")
+	}
+	if config.UploadMaxBytes <= 0 {
+		invalid("UPLOAD_MAX_BYTES", "must be positive")
+	}
+
+	if level, err := zerolog.ParseLevel(config.LogLevel); err != nil || level == zerolog.NoLevel {
+		invalid("LOG_LEVEL", "must be one of trace, debug, info, warn, error, got %q", config.LogLevel)
+	}
+	if config.SlowQueryThreshold <= 0 {
+		invalid("SLOW_QUERY_THRESHOLD", "must be a positive duration")
+	}
+
+	switch config.TracingExporter {
+	case TracingExporterNone, TracingExporterStdout, TracingExporterOTLP:
+	default:
+		invalid("TRACING_EXPORTER", "must be one of none, stdout, otlp, got %q", config.TracingExporter)
+	}
+	if config.TracingOTLPEndpoint != "" {
+		if u, err := url.Parse(config.TracingOTLPEndpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			invalid("TRACING_OTLP_ENDPOINT", "must be an absolute http(s) URL, got %q", config.TracingOTLPEndpoint)
+		}
+	}
+	if config.TracingServiceName == "" {
+		invalid("TRACING_SERVICE_NAME", "is required")
+	}
+	if config.TracingSampleRatio < 0 || config.TracingSampleRatio > 1 {
+		invalid("TRACING_SAMPLE_RATIO", "must be between 0 and 1")
+	}
+
+	if _, err := time.LoadLocation(config.PropertyTimezone); err != nil || config.PropertyTimezone == "" {
+		invalid("PROPERTY_TIMEZONE", "must be an IANA time zone such as Asia/Ho_Chi_Minh, got %q", config.PropertyTimezone)
+	}
+	for key, value := range map[string]string{
+		"CHECK_IN_TIME":  config.CheckInTime,
+		"CHECK_OUT_TIME": config.CheckOutTime,
+	} {
+		if _, err := time.Parse(TimeOfDayLayout, value); err != nil {
+			invalid(key, "must be a time of day like 14:00, got %q", value)
+		}
+	}
+	if config.CheckInWindow < 0 {
+		invalid("CHECK_IN_WINDOW", "must not be negative")
+	}
+
+	if len(fields) > 0 {
+		sort.Strings(fields)
+		return &ConfigError{Fields: fields}
+	}
+	return nil
+}
+
+var dsnPassword = regexp.MustCompile(`(password=)(\S+)`)
+
+// Print writes the effective configuration as KEY=value lines, with secrets
+// masked.
+func (config Config) Print(w io.Writer) error {
+	value := reflect.ValueOf(config)
+	fields := reflect.TypeOf(config)
+
+	lines := make([]string, 0, fields.NumField())
+	for i := 0; i < fields.NumField(); i++ {
+		field := fields.Field(i)
+		text := fmt.Sprint(value.Field(i).Interface())
+		if list, ok := value.Field(i).Interface().([]string); ok {
+			text = strings.Join(list, ",")
+		}
+
+		switch field.Tag.Get("redact") {
+		case "true":
+			if text != "" {
+				text = "********"
+			}
+		case "dsn":
+			if u, err := url.Parse(text); err == nil && u.User != nil {
+				text = u.Redacted()
+			} else {
+				text = dsnPassword.ReplaceAllString(text, "${1}xxxxx")
+			}
+		}
+		lines = append(lines, field.Tag.Get("mapstructure")+"="+text)
+	}
+
+	_, err := io.WriteString(w, strings.Join(lines, "\n")+"\n")
+	return err
 }
@@ -1,3 +0,0 @@
-package utils
-
-const URL_API = `https://bookingappsubserver.onrender.com`
@@ -14,4 +14,13 @@ const (
 	BookingStatus_Canceled  = "CANCELED"
 	BookingStatus_CheckIn   = "CHECKIN"
 	BookingStatus_CheckOut  = "CHECKOUT"
+
+	PromotionType_Percentage = "PERCENTAGE"
+	PromotionType_Fixed      = "FIXED"
+
+	ChargeKind_Tax = "TAX"
+	ChargeKind_Fee = "FEE"
+
+	ChargeBasis_Percentage = "PERCENTAGE"
+	ChargeBasis_PerNight   = "PER_NIGHT"
 )
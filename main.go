@@ -1,10 +1,18 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	// Property time zones must load on hosts without a zoneinfo database
+	_ "time/tzdata"
 
 	"github.com/lancer2672/BookingAppSubServer/api"
 	"github.com/lancer2672/BookingAppSubServer/db"
+	"github.com/lancer2672/BookingAppSubServer/internal/tracing"
 	"github.com/lancer2672/BookingAppSubServer/internal/utils"
 	"github.com/rs/zerolog/log"
 )
@@ -15,11 +23,141 @@ func main() {
 		log.Error().Err(err).Msg("Error loading config")
 		os.Exit(-1)
 	}
-	store := db.ConnectDatabase(serverConfig)
+
+	// "config print" must work with an invalid config so it can be debugged
+	if len(os.Args) < 3 || os.Args[1] != "config" {
+		if err := serverConfig.Validate(); err != nil {
+			log.Error().Msg(err.Error())
+			os.Exit(-1)
+		}
+	}
+
+	if len(os.Args) > 1 {
+		if err := runCommand(serverConfig, os.Args[1:]); err != nil {
+			log.Error().Err(err).Msgf("Error running %q", os.Args[1])
+			os.Exit(-1)
+		}
+		return
+	}
+
+	shutdownTracing, err := tracing.Setup(serverConfig)
+	if err != nil {
+		log.Error().Err(err).Msg("Error setting up tracing")
+		os.Exit(-1)
+	}
+
+	if err := db.CheckSchema(serverConfig.DBSource); err != nil {
+		log.Error().Err(err).Msg("Database schema is not up to date")
+		os.Exit(-1)
+	}
+	conn := db.ConnectDatabase(serverConfig)
+	store := db.NewPostgresStore(conn)
 	server, err := api.NewServer(serverConfig, store)
 	if err != nil {
 		log.Error().Err(err).Msg("Error loading config")
 		os.Exit(-1)
 	}
-	server.Start(serverConfig.ServerAddress)
+	if sqlDB, err := conn.DB(); err == nil {
+		if err := server.MonitorDatabase(sqlDB); err != nil {
+			log.Error().Err(err).Msg("Error exporting database metrics")
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	exitCode := 0
+	if err := server.Start(ctx, serverConfig.ServerAddress); err != nil {
+		log.Error().Err(err).Msg("HTTP server stopped with error")
+		exitCode = -1
+	}
+
+	// Close the connection pool only after every request is done
+	if sqlDB, err := conn.DB(); err == nil {
+		if err := sqlDB.Close(); err != nil {
+			log.Error().Err(err).Msg("Error closing database")
+		}
+	}
+	// Flush the spans still buffered
+	flushCtx, cancel := context.WithTimeout(context.Background(), serverConfig.ShutdownTimeout)
+	if err := shutdownTracing(flushCtx); err != nil {
+		log.Error().Err(err).Msg("Error flushing traces")
+	}
+	cancel()
+	log.Info().Msg("server stopped")
+	os.Exit(exitCode)
+}
+
+const usage = `usage:
+  server                     start the HTTP server
+  server migrate up          apply all pending migrations
+  server migrate down [N]    roll back the last N migrations (default 1)
+  server migrate status      show the current and latest schema version
+  server config print        show the effective configuration, secrets redacted`
+
+// runCommand executes a command-line subcommand instead of starting the server.
+func runCommand(config utils.Config, args []string) error {
+	switch args[0] {
+	case "migrate":
+		return runMigrate(config, args[1:])
+	case "config":
+		return runConfig(config, args[1:])
+	default:
+		return fmt.Errorf("unknown command %q\n%s", args[0], usage)
+	}
+}
+
+func runMigrate(config utils.Config, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing migrate action\n%s", usage)
+	}
+
+	migrator, err := db.NewMigrator(config.DBSource)
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+
+	switch args[0] {
+	case "up":
+		if err := migrator.Up(); err != nil {
+			return err
+		}
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("invalid number of steps %q", args[1])
+			}
+		}
+		if err := migrator.Down(steps); err != nil {
+			return err
+		}
+	case "status":
+	default:
+		return fmt.Errorf("unknown migrate action %q\n%s", args[0], usage)
+	}
+
+	status, err := migrator.Status()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("current version: %d\nlatest version:  %d\ndirty:           %t\nup to date:      %t\n",
+		status.Current, status.Latest, status.Dirty, status.UpToDate())
+	return nil
+}
+
+func runConfig(config utils.Config, args []string) error {
+	if len(args) == 0 || args[0] != "print" {
+		return fmt.Errorf("unknown config action\n%s", usage)
+	}
+	if err := config.Print(os.Stdout); err != nil {
+		return err
+	}
+	if err := config.Validate(); err != nil {
+		fmt.Println()
+		fmt.Println(err)
+	}
+	return nil
 }